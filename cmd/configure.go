@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// configureSettings are the key=value changes given with configure --set
+var configureSettings []string
+
+var configureCmd = &cobra.Command{
+	Use:   "configure <service>",
+	Short: "Change the settings of an installed service",
+	Long: `Change settings of an installed service with --set key=value, using the
+same keys as stack files, e.g.
+
+  bootup configure prometheus --set retention_time=30d --set external_url=https://prometheus.example.com/
+
+The configuration and systemd unit are rewritten, the new configuration is
+checked before anything is replaced and the service is restarted. If it
+does not come back healthy the previous files are restored and it is
+restarted with them. An empty value resets a setting to its default.
+
+Without --set the current settings are printed.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, name := range services.GetServiceNames() {
+			if services.CanConfigure(name) {
+				names = append(names, name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		service := args[0]
+
+		if len(configureSettings) == 0 {
+			settings, err := services.ConfiguredSettings(service)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			keys := make([]string, 0, len(settings))
+			for key := range settings {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SETTING\tVALUE")
+			for _, key := range keys {
+				fmt.Fprintf(w, "%s\t%s\n", key, settings[key])
+			}
+			w.Flush()
+			return
+		}
+
+		changes := make(map[string]string)
+		for _, override := range configureSettings {
+			key, value, ok := strings.Cut(override, "=")
+			if !ok {
+				fmt.Printf("invalid --set %q, expected key=value\n", override)
+				os.Exit(1)
+			}
+			changes[key] = value
+		}
+
+		report := func() {}
+		if dryRun {
+			report = startDryRun()
+		}
+		defer report()
+		services.SetRollback(!noRollback)
+
+		if err := services.ConfigureService(service, changes); err != nil {
+			utils.PrintError(fmt.Sprintf("Failed to configure %s: %v", service, err))
+			report()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configureCmd.Flags().StringArrayVar(&configureSettings, "set", nil, "Change a setting, e.g. retention_time=30d (repeatable)")
+	configureCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the files and commands the change would write and run without changing the host")
+	configureCmd.Flags().BoolVar(&noRollback, "no-rollback", false, "Leave the new files in place if the service does not come back healthy")
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/amirkh8006/bootup-cli/internal/secrets"
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var credentialsJSON bool
+
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Show or rotate the credentials bootup generated",
+	Long: `bootup generates random credentials instead of well-known defaults for
+RabbitMQ (admin user), Grafana (admin user), PostgreSQL (postgres user),
+SeaweedFS and RustFS (S3 keys), and keeps them in a secrets file only root
+can read.`,
+}
+
+var credentialsShowCmd = &cobra.Command{
+	Use:               "show <service>",
+	Short:             "Print the generated credentials of a service",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeCredentialServices,
+	Run: func(cmd *cobra.Command, args []string) {
+		credentials, err := services.ShowCredentials(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		printCredentials(credentials)
+	},
+}
+
+var credentialsRotateCmd = &cobra.Command{
+	Use:   "rotate <service>",
+	Short: "Replace the credentials of a service with new random ones",
+	Long: `Generate new credentials, apply them to the running service and store
+them. Clients using the old credentials need to be updated; a Postgres
+Exporter installed with the generated PostgreSQL password is updated too.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeCredentialServices,
+	Run: func(cmd *cobra.Command, args []string) {
+		credentials, err := services.RotateCredentials(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		printCredentials(credentials)
+	},
+}
+
+func printCredentials(credentials secrets.Credentials) {
+	if credentialsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(credentials); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(credentials))
+	for key := range credentials {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key, credentials[key])
+	}
+	w.Flush()
+}
+
+func completeCredentialServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := services.CredentialServices()
+	slices.Sort(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	credentialsCmd.PersistentFlags().BoolVar(&credentialsJSON, "json", false, "Print the credentials as JSON")
+	credentialsCmd.AddCommand(credentialsShowCmd)
+	credentialsCmd.AddCommand(credentialsRotateCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsFollow bool
+	logsLines  int
+)
+
+// lifecycleCmd wraps an init system verb for the units of one or more
+// installed services
+func lifecycleCmd(verb, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   verb + " <service>...",
+		Short: short,
+		Long: fmt.Sprintf(`Run systemctl %s (or its OpenRC equivalent) on the units behind the
+given services, so you do not need to know that mongodb runs as mongod or
+grafana as grafana-server.`, verb),
+		Args: cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return remainingServiceNames(args), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			failed := false
+			for _, name := range args {
+				if err := services.ControlService(name, verb); err != nil {
+					utils.PrintError(fmt.Sprintf("Failed to %s %s: %v", verb, name, err))
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <service>",
+	Short: "Show the journal of an installed service",
+	Long: `Show the recent systemd journal entries of the units behind a service.
+With -f new entries are printed as they arrive.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return services.GetServiceNames(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := services.ServiceLogs(args[0], logsLines, logsFollow); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new log entries")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 100, "Number of recent entries to show")
+}
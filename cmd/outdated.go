@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var outdatedJSON bool
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Compare installed service versions with the latest releases",
+	Long: `Show, for every installed service, the installed version, the version
+bootup installs by default and the newest upstream release.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var installed []string
+		for _, name := range services.GetServiceNames() {
+			if services.IsServiceInstalled(name) {
+				installed = append(installed, name)
+			}
+		}
+		sort.Strings(installed)
+
+		reports := services.CheckVersions(installed)
+
+		if outdatedJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(reports); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(reports) == 0 {
+			fmt.Println("No installed services found")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tINSTALLED\tDEFAULT\tLATEST\tSTATUS")
+		for _, report := range reports {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", report.Service,
+				orDash(report.Installed), orDash(report.Default), orDash(report.Latest), versionStatus(report))
+		}
+		w.Flush()
+
+		for _, report := range reports {
+			if report.Error != "" {
+				fmt.Printf("\n⚠️  %s: %s", report.Service, report.Error)
+			}
+		}
+		fmt.Println()
+	},
+}
+
+// versionStatus summarises a version report for the table
+func versionStatus(report services.VersionReport) string {
+	switch {
+	case report.Outdated:
+		return "outdated"
+	case report.Installed == "" || report.Latest == "":
+		return "unknown"
+	default:
+		return "up to date"
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&outdatedJSON, "json", false, "Print the report as JSON")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var preflightJSON bool
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight [service...]",
+	Short: "Check whether services can be installed on this host",
+	Long: `Run the checks install runs before changing anything: distribution and
+architecture support, sudo, network access to the download hosts, free
+ports and disk space, prerequisites and conflicting packages.
+
+Exits with status 1 when any check fails.`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return remainingServiceNames(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results := make(map[string][]services.Check)
+		failed := false
+		for _, name := range args {
+			checks, err := services.Preflight(name)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			results[name] = checks
+			for _, check := range checks {
+				if check.Status == services.CheckFail {
+					failed = true
+				}
+			}
+		}
+
+		if preflightJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(results); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		} else {
+			for _, name := range args {
+				fmt.Printf("🔎 %s\n", name)
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "STATUS\tCHECK\tDETAIL")
+				for _, check := range results[name] {
+					fmt.Fprintf(w, "%s\t%s\t%s\n", check.Status, check.Name, check.Detail)
+				}
+				w.Flush()
+				fmt.Println()
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	preflightCmd.Flags().BoolVar(&preflightJSON, "json", false, "Print the checks as JSON")
+}
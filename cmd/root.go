@@ -3,15 +3,36 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/amirkh8006/bootup-cli/internal/services"
 	"github.com/amirkh8006/bootup-cli/internal/tui"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 // Version will be set during build time
 var Version = "v1.0.0"
 
+// dryRun makes install record the actions it would take instead of running them
+var dryRun bool
+
+// noRollback leaves a failed install's changes in place for debugging
+var noRollback bool
+
+// skipPreflight installs without running the preflight checks first
+var skipPreflight bool
+
+// installVersion is the version requested with install --version
+var installVersion string
+
+// installPorts are the name=value port overrides given with install --port
+var installPorts []string
+
+// installSettings are the key=value settings given with install --set
+var installSettings []string
+
 var rootCmd = &cobra.Command{
 	Use:     "bootup",
 	Short:   "Bootup is a server setup CLI tool",
@@ -41,7 +62,7 @@ var listServicesCmd = &cobra.Command{
 			if serviceList, exists := servicesByCategory[category]; exists {
 				fmt.Printf("📁 %s:\n", category)
 				for _, service := range serviceList {
-					fmt.Printf("   - %s: %s\n", service.Name, service.Description)
+					fmt.Printf("   - %s: %s%s\n", service.Name, service.Description, installedLabel(service.Name))
 				}
 				fmt.Println()
 			}
@@ -50,37 +71,180 @@ var listServicesCmd = &cobra.Command{
 }
 
 var installCmd = &cobra.Command{
-	Use:     "install [service]",
+	Use:     "install [service...]",
 	Aliases: []string{"i"},
-	Short:   "Install a service (Alias: i)",
-	Args:    cobra.MinimumNArgs(1),
+	Short:   "Install one or more services (Alias: i)",
+	Long: `Install one or more services. Missing prerequisites, such as Java for Kafka
+or PostgreSQL for postgres_exporter, are installed first.
+
+Services installed from release tarballs can be pinned to a version with
+service@version, e.g. bootup install prometheus@3.2.0 node_exporter@1.8.2
+
+Ports can be changed with --port name=value, or --port service.name=value
+when installing several services, e.g. bootup install prometheus --port port=9091
+
+Other settings are given the same way with --set, e.g.
+bootup install prometheus --set retention_time=30d --set scrape_interval=30s`,
+	Args: cobra.MinimumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) != 0 {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
-		return services.GetServiceNames(), cobra.ShellCompDirectiveNoFileComp
+		return remainingServiceNames(args), cobra.ShellCompDirectiveNoFileComp
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		service := args[0]
-
-		installer, err := services.GetServiceInstaller(service)
-		if err != nil {
-			fmt.Printf("Service %s is not supported yet\n", service)
+		if installVersion != "" && len(args) > 1 {
+			fmt.Println("--version can only be used when installing a single service, use service@version instead")
 			os.Exit(1)
 		}
 
-		if err := installer(); err != nil {
+		names := make([]string, 0, len(args))
+		for _, arg := range args {
+			service, version, err := services.ParseServiceVersion(arg)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if !services.IsValidService(service) {
+				fmt.Printf("Service %s is not supported yet\n", service)
+				os.Exit(1)
+			}
+			if version == "" {
+				version = installVersion
+			}
+			if version != "" {
+				services.SetInstallOptions(service, services.InstallOptions{Version: version})
+			}
+			names = append(names, service)
+		}
+		for _, override := range installPorts {
+			if err := setPortOverride(override, names); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		for _, override := range installSettings {
+			if err := setSettingOverride(override, names); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		utils.SetAssumeYes(assumeYes)
+
+		report := func() {}
+		if dryRun {
+			report = startDryRun()
+		}
+		defer report()
+		services.SetRollback(!noRollback)
+		services.SetPreflight(!skipPreflight)
+
+		if err := services.InstallServices(names); err != nil {
 			fmt.Println(err)
+			report()
 			os.Exit(1)
 		}
 	},
 }
 
+// setPortOverride applies a --port name=value or service.name=value flag
+func setPortOverride(override string, names []string) error {
+	service, name, value, err := parseOverride("--port", override, names)
+	if err != nil {
+		return err
+	}
+	return services.SetPort(service, name, value)
+}
+
+// setSettingOverride applies a --set key=value or service.key=value flag
+func setSettingOverride(override string, names []string) error {
+	service, key, value, err := parseOverride("--set", override, names)
+	if err != nil {
+		return err
+	}
+	services.SetSetting(service, key, value)
+	return nil
+}
+
+// parseOverride splits a name=value or service.name=value flag. The service
+// may be left out when a single one is being installed.
+func parseOverride(flag, override string, names []string) (service, name, value string, err error) {
+	spec, value, ok := strings.Cut(override, "=")
+	if !ok {
+		return "", "", "", fmt.Errorf("invalid %s %q, expected name=value", flag, override)
+	}
+	service, name, qualified := strings.Cut(spec, ".")
+	if !qualified {
+		if len(names) != 1 {
+			return "", "", "", fmt.Errorf("%s %s is ambiguous when installing several services, use service.%s=%s", flag, override, spec, value)
+		}
+		service, name = names[0], spec
+	}
+	return service, name, value, nil
+}
+
+// remainingServiceNames returns the service names not already given on the command line
+func remainingServiceNames(args []string) []string {
+	var names []string
+	for _, name := range services.GetServiceNames() {
+		if !slices.Contains(args, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// installedLabel describes whether a service is installed, including the
+// version bootup recorded when it installed it
+func installedLabel(serviceName string) string {
+	if record, ok := services.GetInstallRecord(serviceName); ok {
+		if record.Version != "" {
+			return fmt.Sprintf(" [installed %s by bootup]", record.Version)
+		}
+		return " [installed by bootup]"
+	}
+	if services.IsServiceInstalled(serviceName) {
+		return " [installed]"
+	}
+	return ""
+}
+
+func init() {
+	installCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the commands, files and systemd units an install would create without changing the host")
+	installCmd.Flags().BoolVar(&noRollback, "no-rollback", false, "Leave the changes of a failed install in place instead of undoing them")
+	installCmd.Flags().StringArrayVar(&installPorts, "port", nil, "Change a port, e.g. port=9091 or kafka.controller_port=9095 (repeatable)")
+	installCmd.Flags().StringArrayVar(&installSettings, "set", nil, "Set a service setting as a stack file would, e.g. retention_time=30d or prometheus.external_url=https://example.com/ (repeatable)")
+	installCmd.Flags().BoolVar(&skipPreflight, "skip-preflight", false, "Install without checking ports, disk space, network access and sudo first")
+	installCmd.Flags().StringVar(&installVersion, "version", "", "Version to install instead of asking, e.g. 1.22.3 for golang or 8.3 for php")
+	installCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Answer every prompt with its default, choosing the recommended version")
+}
+
+// startDryRun switches to the recording executor and returns a function that
+// prints the recorded actions. os.Exit skips deferred calls, so a failing
+// command calls it before exiting.
+func startDryRun() func() {
+	utils.PrintWarning("Dry run: no changes will be made to this host")
+	recorder := utils.NewDryRunExecutor()
+	utils.SetExecutor(recorder)
+	return func() { recorder.Report(os.Stdout) }
+}
+
 func Execute() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	rootCmd.AddCommand(listServicesCmd)
 	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(uninstallCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(preflightCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(lifecycleCmd("start", "Start an installed service"))
+	rootCmd.AddCommand(lifecycleCmd("stop", "Stop an installed service"))
+	rootCmd.AddCommand(lifecycleCmd("restart", "Restart an installed service"))
+	rootCmd.AddCommand(lifecycleCmd("enable", "Start an installed service at boot"))
+	rootCmd.AddCommand(lifecycleCmd("disable", "Keep an installed service from starting at boot"))
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(credentialsCmd)
+	rootCmd.AddCommand(configureCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
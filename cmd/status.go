@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var statusJSON bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status [service...]",
+	Short: "Show whether installed services are running and healthy",
+	Long: `Show, for every installed service or the ones given, the state of its
+systemd unit, its main PID and uptime, which of its ports are listening, the
+installed version and the result of a health check against the service
+itself, such as /-/healthy for Prometheus or PING for Redis.
+
+Exits with status 1 when a service is not running or fails its health check.`,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return remainingServiceNames(args), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		names := args
+		if len(names) == 0 {
+			for _, name := range services.GetServiceNames() {
+				if services.IsServiceInstalled(name) {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+		}
+
+		statuses := []services.ServiceStatus{}
+		for _, name := range names {
+			status, err := services.Status(name)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			statuses = append(statuses, status)
+		}
+
+		if statusJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(statuses); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		} else if len(statuses) == 0 {
+			fmt.Println("No installed services found")
+			return
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SERVICE\tSTATE\tPID\tUPTIME\tPORTS\tVERSION\tHEALTH")
+			for _, status := range statuses {
+				pid := "-"
+				if status.PID > 0 {
+					pid = strconv.Itoa(status.PID)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", status.Service, orDash(status.State), pid,
+					formatUptime(status.UptimeSeconds), formatPorts(status.Ports), orDash(status.Version), orDash(string(status.Health)))
+			}
+			w.Flush()
+
+			for _, status := range statuses {
+				if status.HealthDetail != "" {
+					fmt.Printf("\n⚠️  %s: %s", status.Service, status.HealthDetail)
+				}
+			}
+			fmt.Println()
+		}
+
+		for _, status := range statuses {
+			if !status.OK() {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// formatUptime prints the two largest units of an uptime, e.g. 3d4h or 12m5s
+func formatUptime(seconds int64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	d := time.Duration(seconds) * time.Second
+	days := int(d.Hours()) / 24
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// formatPorts lists the ports of a service, marking those nothing listens on
+func formatPorts(ports []services.PortStatus) string {
+	if len(ports) == 0 {
+		return "-"
+	}
+	var parts []string
+	for _, port := range ports {
+		if port.Listening {
+			parts = append(parts, strconv.Itoa(port.Number))
+		} else {
+			parts = append(parts, strconv.Itoa(port.Number)+" (closed)")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the status as JSON")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purge           bool
+	assumeYes       bool
+	uninstallDryRun bool
+)
+
+var uninstallCmd = &cobra.Command{
+	Use:     "uninstall [service]",
+	Aliases: []string{"rm"},
+	Short:   "Uninstall a service (Alias: rm)",
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return services.GetServiceNames(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		service := args[0]
+
+		if !services.IsValidService(service) {
+			fmt.Printf("Service %s is not supported yet\n", service)
+			os.Exit(1)
+		}
+
+		plan, err := services.GetServiceUninstallPlan(service, purge)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if purge && !assumeYes && !uninstallDryRun {
+			utils.PrintWarning(fmt.Sprintf("--purge permanently deletes the data and configuration of %s", service))
+			if !utils.AskYesNo("Continue?") {
+				fmt.Println("Aborted")
+				return
+			}
+		}
+
+		report := func() {}
+		if uninstallDryRun {
+			report = startDryRun()
+		}
+		defer report()
+
+		if err := plan.Execute(); err != nil {
+			fmt.Println(err)
+			report()
+			os.Exit(1)
+		}
+
+		services.ForgetService(service)
+		services.ForgetCredentials(service)
+	},
+}
+
+func init() {
+	uninstallCmd.Flags().BoolVar(&purge, "purge", false, "Also delete data and configuration directories")
+	uninstallCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	uninstallCmd.Flags().BoolVar(&uninstallDryRun, "dry-run", false, "Print the removal steps without changing the host")
+}
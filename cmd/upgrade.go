@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/amirkh8006/bootup-cli/internal/services"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var upgradeAll bool
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [service]",
+	Short: "Upgrade an installed service to its newest version",
+	Long: `Upgrade an installed service in place to the newest available version.
+Configuration and data directories are kept.
+
+With --all every service recorded in the bootup state file is checked.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if upgradeAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return services.GetServiceNames(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		targets := args
+		if upgradeAll {
+			targets = services.RecordedServices()
+			if len(targets) == 0 {
+				utils.PrintInfo("No services installed by bootup were found")
+				return
+			}
+		} else if !services.IsValidService(args[0]) {
+			fmt.Printf("Service %s is not supported yet\n", args[0])
+			os.Exit(1)
+		}
+
+		report := func() {}
+		if dryRun {
+			report = startDryRun()
+		}
+		defer report()
+		services.SetRollback(!noRollback)
+
+		var failed []string
+		upgraded := 0
+		for _, service := range targets {
+			result, err := services.UpgradeService(service)
+			if err != nil {
+				utils.PrintError(fmt.Sprintf("Failed to upgrade %s: %v", service, err))
+				failed = append(failed, service)
+				continue
+			}
+			if result.Upgraded {
+				upgraded++
+			}
+		}
+
+		if upgradeAll {
+			fmt.Printf("\n%d upgraded, %d failed, %d checked\n", upgraded, len(failed), len(targets))
+		}
+		if len(failed) > 0 {
+			report()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeAll, "all", false, "Upgrade every service installed by bootup")
+	upgradeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the upgrade steps without changing the host")
+	upgradeCmd.Flags().BoolVar(&noRollback, "no-rollback", false, "Leave the changes of a failed upgrade in place instead of undoing them")
+}
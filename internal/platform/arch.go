@@ -0,0 +1,42 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Arch is a CPU architecture under the names the different release
+// channels use for it
+type Arch struct {
+	Name    string // Go and most release tarballs, e.g. amd64, arm64
+	Machine string // uname -m and Rust targets, e.g. x86_64, aarch64
+	Debian  string // dpkg, apt sources and JVM directories, e.g. amd64, arm64
+}
+
+// arches lists the architectures bootup knows, keyed by GOARCH
+var arches = map[string]Arch{
+	"amd64": {Name: "amd64", Machine: "x86_64", Debian: "amd64"},
+	"arm64": {Name: "arm64", Machine: "aarch64", Debian: "arm64"},
+	"386":   {Name: "386", Machine: "i686", Debian: "i386"},
+	"arm":   {Name: "arm", Machine: "armv7l", Debian: "armhf"},
+}
+
+// DetectArch returns the architecture bootup is running on. The release
+// archives of bootup itself are built per architecture, so GOARCH matches
+// the machine; BOOTUP_ARCH overrides it to preview plans for another one.
+func DetectArch() (Arch, error) {
+	name := os.Getenv("BOOTUP_ARCH")
+	if name == "" {
+		name = runtime.GOARCH
+	}
+	arch, ok := arches[name]
+	if !ok {
+		return Arch{}, fmt.Errorf("unsupported architecture %s", name)
+	}
+	return arch, nil
+}
+
+func (a Arch) String() string {
+	return a.Name
+}
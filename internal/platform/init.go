@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// ServiceCommand returns the command that starts, stops, restarts, reloads,
+// enables or disables a service with the distribution's init system
+func (o *OS) ServiceCommand(verb, unit string) []string {
+	if !o.OpenRC() {
+		return []string{"sudo", "systemctl", verb, unit}
+	}
+
+	switch verb {
+	case "enable":
+		return []string{"sudo", "rc-update", "add", unit, "default"}
+	case "disable":
+		return []string{"sudo", "rc-update", "del", unit, "default"}
+	}
+	return []string{"sudo", "rc-service", unit, verb}
+}
+
+// ServiceEnabled reports whether a service starts at boot
+func (o *OS) ServiceEnabled(unit string) bool {
+	if o.OpenRC() {
+		_, err := os.Lstat("/etc/runlevels/default/" + unit)
+		return err == nil
+	}
+	return utils.CheckCommand("systemctl", "is-enabled", "--quiet", unit)
+}
+
+// UnitStatus is the live state of a service as the init system reports it
+type UnitStatus struct {
+	State string    // active, inactive, failed, activating, or started and stopped on OpenRC
+	PID   int       // main process, 0 when unknown or not running
+	Since time.Time // when the service entered its current state, zero when unknown
+}
+
+// ServiceStatus asks the init system for the state of a service
+func (o *OS) ServiceStatus(unit string) UnitStatus {
+	if o.OpenRC() {
+		// rc-service prints " * status: started"
+		output, _ := utils.CommandCombinedOutput("rc-service", unit, "status")
+		_, state, _ := strings.Cut(output, "status: ")
+		if state == "" {
+			state = "unknown"
+		}
+		return UnitStatus{State: strings.TrimSpace(state)}
+	}
+
+	output, err := utils.CommandOutput("systemctl", "show", unit,
+		"--property=LoadState,ActiveState,MainPID,ActiveEnterTimestampMonotonic")
+	if err != nil {
+		return UnitStatus{State: "unknown"}
+	}
+	properties := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			properties[key] = value
+		}
+	}
+	if properties["LoadState"] == "not-found" {
+		return UnitStatus{State: "not-found"}
+	}
+
+	status := UnitStatus{State: properties["ActiveState"]}
+	status.PID, _ = strconv.Atoi(properties["MainPID"])
+	// The monotonic timestamp counts microseconds since boot, which unlike
+	// the wall-clock one does not depend on the systemd version or time zone
+	if entered, err := strconv.ParseInt(properties["ActiveEnterTimestampMonotonic"], 10, 64); err == nil && entered > 0 {
+		if boot, ok := bootTime(); ok {
+			status.Since = boot.Add(time.Duration(entered) * time.Microsecond)
+		}
+	}
+	return status
+}
+
+// bootTime returns when the host booted, from /proc/uptime
+func bootTime() (time.Time, bool) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return time.Time{}, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Now().Add(-time.Duration(uptime * float64(time.Second))), true
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the socket state /proc/net/tcp reports for listening sockets
+const tcpListen = "0A"
+
+// ListeningPorts returns the TCP ports with a listening socket on any
+// address, read from /proc/net/tcp and /proc/net/tcp6. Unlike binding the
+// port, this works for privileged ports without root.
+func ListeningPorts() (map[int]bool, error) {
+	ports := make(map[int]bool)
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if err := readListening(path, ports); err != nil {
+			// IPv6 may be disabled
+			if errors.Is(err, fs.ErrNotExist) && path == "/proc/net/tcp6" {
+				continue
+			}
+			return nil, err
+		}
+	}
+	return ports, nil
+}
+
+// readListening adds the listening ports in a /proc/net/tcp style file.
+// Each line holds "sl local_address rem_address st ...", with the address
+// as hex IP:port.
+func readListening(path string, ports map[int]bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != tcpListen {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if port, err := strconv.ParseInt(hexPort, 16, 32); err == nil {
+			ports[int(port)] = true
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,62 @@
+package platform
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadListening(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []int
+	}{
+		{
+			name: "tcp",
+			content: `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 21734 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:18EB 00000000:0000 0A 00000000:00000000 00:00000000 00000000   113        0 19622 1 0000000000000000 100 0 0 10 0
+   2: 0F02000A:0016 0202000A:C5D2 01 00000000:00000000 02:0009D5A3 00000000     0        0 30562 2 0000000000000000 20 4 29 10 -1
+`,
+			want: []int{6379, 8080},
+		},
+		{
+			name: "tcp6",
+			content: `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 23981 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:2382 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 24100 1 0000000000000000 100 0 0 10 0
+   2: 0000000000000000FFFF00000F02000A:0016 0000000000000000FFFF00000202000A:C5D4 01 00000000:00000000 02:000A7B8E 00000000     0        0 30611 2 0000000000000000 20 4 31 10 -1
+`,
+			want: []int{80, 9090},
+		},
+		{
+			name:    "header only",
+			content: "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tcp")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ports := make(map[int]bool)
+			if err := readListening(path, ports); err != nil {
+				t.Fatalf("readListening() error = %v", err)
+			}
+			if got := slices.Sorted(maps.Keys(ports)); !slices.Equal(got, tt.want) {
+				t.Errorf("readListening() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadListeningMissingFile(t *testing.T) {
+	if err := readListening(filepath.Join(t.TempDir(), "tcp6"), make(map[int]bool)); err == nil {
+		t.Error("readListening() of a missing file succeeded")
+	}
+}
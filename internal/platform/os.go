@@ -0,0 +1,144 @@
+package platform
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Family groups distributions that share a package format and repositories
+type Family string
+
+const (
+	Debian    Family = "debian" // Debian, Ubuntu and derivatives
+	RHEL      Family = "rhel"   // RHEL, Rocky, AlmaLinux, CentOS Stream and Fedora
+	Alpine    Family = "alpine"
+	ArchLinux Family = "arch"
+)
+
+// OS describes the running distribution as reported by /etc/os-release
+type OS struct {
+	ID         string   // e.g. ubuntu, debian, rocky, alpine
+	IDLike     []string // distributions this one derives from
+	VersionID  string   // e.g. 24.04, 12, 9.4
+	Codename   string   // e.g. noble, bookworm; empty on most non-Debian systems
+	PrettyName string
+	Family     Family
+
+	// Base is the distribution whose third-party repositories apply, e.g.
+	// ubuntu for Linux Mint
+	Base string
+}
+
+// String returns the human readable name of the distribution
+func (o *OS) String() string {
+	if o.PrettyName != "" {
+		return o.PrettyName
+	}
+	return strings.TrimSpace(o.ID + " " + o.VersionID)
+}
+
+// Release returns the release third-party repositories are published per:
+// the codename on the Debian family and the major version elsewhere
+func (o *OS) Release() string {
+	if o.Family == Debian {
+		return o.Codename
+	}
+	major, _, _ := strings.Cut(o.VersionID, ".")
+	return major
+}
+
+// OpenRC reports whether services are managed by OpenRC instead of systemd
+func (o *OS) OpenRC() bool {
+	return o.Family == Alpine
+}
+
+var (
+	detectOnce sync.Once
+	detected   *OS
+	detectErr  error
+)
+
+// Detect reads /etc/os-release, or the file named by BOOTUP_OS_RELEASE, once
+// and returns the running distribution. When the file does not exist, as on
+// a development machine running a dry run, Debian is assumed since that is
+// what bootup was originally written for.
+func Detect() (*OS, error) {
+	detectOnce.Do(func() {
+		path := os.Getenv("BOOTUP_OS_RELEASE")
+		if path == "" {
+			path = "/etc/os-release"
+		}
+
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			detected = &OS{ID: "debian", Family: Debian, Base: "debian"}
+			return
+		}
+		if err != nil {
+			detectErr = fmt.Errorf("failed to read %s: %w", path, err)
+			return
+		}
+
+		detected = Parse(string(content))
+		if detected.Family == "" {
+			detectErr = fmt.Errorf("unsupported distribution %s", detected)
+		}
+	})
+	return detected, detectErr
+}
+
+// Parse reads the KEY=value pairs of an os-release file. Family is left
+// empty for distributions bootup does not know.
+func Parse(content string) *OS {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+
+	info := &OS{
+		ID:         values["ID"],
+		IDLike:     strings.Fields(values["ID_LIKE"]),
+		VersionID:  values["VERSION_ID"],
+		Codename:   values["UBUNTU_CODENAME"],
+		PrettyName: values["PRETTY_NAME"],
+	}
+	// Ubuntu derivatives name their own release in VERSION_CODENAME, but
+	// use the repositories of the Ubuntu release they are built on
+	if info.Codename == "" {
+		info.Codename = values["VERSION_CODENAME"]
+	}
+
+	for _, id := range slices.Concat([]string{info.ID}, info.IDLike) {
+		if f, ok := families[id]; ok {
+			info.Family = f
+			info.Base = id
+			break
+		}
+	}
+	return info
+}
+
+// families maps the distributions bootup knows, and the ones others derive
+// from, to their family
+var families = map[string]Family{
+	"debian":    Debian,
+	"ubuntu":    Debian,
+	"rhel":      RHEL,
+	"centos":    RHEL,
+	"fedora":    RHEL,
+	"rocky":     RHEL,
+	"almalinux": RHEL,
+	"alpine":    Alpine,
+	"arch":      ArchLinux,
+}
@@ -0,0 +1,111 @@
+package platform
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    OS
+		release string
+	}{
+		{
+			name: "ubuntu",
+			content: `PRETTY_NAME="Ubuntu 24.04.1 LTS"
+NAME="Ubuntu"
+VERSION_ID="24.04"
+VERSION_CODENAME=noble
+ID=ubuntu
+ID_LIKE=debian
+UBUNTU_CODENAME=noble
+`,
+			want:    OS{ID: "ubuntu", IDLike: []string{"debian"}, VersionID: "24.04", Codename: "noble", PrettyName: "Ubuntu 24.04.1 LTS", Family: Debian, Base: "ubuntu"},
+			release: "noble",
+		},
+		{
+			name: "linux mint uses the ubuntu codename",
+			content: `NAME="Linux Mint"
+VERSION_ID="22"
+VERSION_CODENAME=wilma
+ID=linuxmint
+ID_LIKE="ubuntu debian"
+UBUNTU_CODENAME=noble
+`,
+			want:    OS{ID: "linuxmint", IDLike: []string{"ubuntu", "debian"}, VersionID: "22", Codename: "noble", Family: Debian, Base: "ubuntu"},
+			release: "noble",
+		},
+		{
+			name: "single quotes and comments",
+			content: `# Debian 12
+ID='debian'
+VERSION_ID='12'
+VERSION_CODENAME='bookworm'
+PRETTY_NAME='Debian GNU/Linux 12 (bookworm)'
+`,
+			want:    OS{ID: "debian", VersionID: "12", Codename: "bookworm", PrettyName: "Debian GNU/Linux 12 (bookworm)", Family: Debian, Base: "debian"},
+			release: "bookworm",
+		},
+		{
+			name: "rocky",
+			content: `NAME="Rocky Linux"
+VERSION_ID="9.4"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+PRETTY_NAME="Rocky Linux 9.4 (Blue Onyx)"
+`,
+			want:    OS{ID: "rocky", IDLike: []string{"rhel", "centos", "fedora"}, VersionID: "9.4", PrettyName: "Rocky Linux 9.4 (Blue Onyx)", Family: RHEL, Base: "rocky"},
+			release: "9",
+		},
+		{
+			name: "alpine",
+			content: `NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.20.3
+PRETTY_NAME="Alpine Linux v3.20"
+`,
+			want:    OS{ID: "alpine", VersionID: "3.20.3", PrettyName: "Alpine Linux v3.20", Family: Alpine, Base: "alpine"},
+			release: "3",
+		},
+		{
+			name: "arch without a version",
+			content: `NAME="Arch Linux"
+ID=arch
+BUILD_ID=rolling
+`,
+			want: OS{ID: "arch", Family: ArchLinux, Base: "arch"},
+		},
+		{
+			name: "unknown distribution",
+			content: `ID=gentoo
+VERSION_ID=2.15
+`,
+			want:    OS{ID: "gentoo", VersionID: "2.15"},
+			release: "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.content)
+			if got.ID != tt.want.ID || got.VersionID != tt.want.VersionID || got.Codename != tt.want.Codename ||
+				got.PrettyName != tt.want.PrettyName || got.Family != tt.want.Family || got.Base != tt.want.Base ||
+				!slices.Equal(got.IDLike, tt.want.IDLike) {
+				t.Errorf("Parse() = %+v, want %+v", *got, tt.want)
+			}
+			if release := got.Release(); release != tt.release {
+				t.Errorf("Release() = %q, want %q", release, tt.release)
+			}
+		})
+	}
+}
+
+func TestOpenRC(t *testing.T) {
+	for family, want := range map[Family]bool{Debian: false, RHEL: false, Alpine: true, ArchLinux: false} {
+		if got := (&OS{Family: family}).OpenRC(); got != want {
+			t.Errorf("OpenRC() on %s = %v, want %v", family, got, want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// PackageManager builds the commands that install and remove distribution
+// packages and answers read-only questions about them. Commands are returned
+// rather than run so install plans can show and roll them back like any
+// other step.
+type PackageManager interface {
+	// Name is the package manager's command, e.g. apt-get or dnf
+	Name() string
+
+	UpdateCommand() []string
+	InstallCommand(packages []string) []string
+	UpgradeCommand(packages []string) []string
+	RemoveCommand(packages []string, purge bool) []string
+
+	// AutoremoveCommand removes dependencies nothing needs anymore, or
+	// returns nil when removing a package already does
+	AutoremoveCommand() []string
+
+	Installed(pkg string) bool
+	InstalledVersion(pkg string) string
+	CandidateVersion(pkg string) (string, error)
+}
+
+// PackageManager returns the package manager of the distribution
+func (o *OS) PackageManager() (PackageManager, error) {
+	switch o.Family {
+	case Debian:
+		return apt{}, nil
+	case RHEL:
+		// RHEL 7 and CentOS 7 only ship yum
+		if _, err := exec.LookPath("dnf"); err != nil {
+			if _, err := exec.LookPath("yum"); err == nil {
+				return dnf{command: "yum"}, nil
+			}
+		}
+		return dnf{command: "dnf"}, nil
+	case Alpine:
+		return apk{}, nil
+	case ArchLinux:
+		return pacman{}, nil
+	}
+	return nil, fmt.Errorf("bootup does not know the package manager of %s", o)
+}
+
+// apt manages packages on Debian and Ubuntu
+type apt struct{}
+
+func (apt) Name() string { return "apt-get" }
+
+func (apt) UpdateCommand() []string {
+	return []string{"sudo", "apt-get", "update", "-y"}
+}
+
+func (apt) InstallCommand(packages []string) []string {
+	return append([]string{"sudo", "apt-get", "install", "-y"}, packages...)
+}
+
+func (apt) UpgradeCommand(packages []string) []string {
+	return append([]string{"sudo", "apt-get", "install", "--only-upgrade", "-y"}, packages...)
+}
+
+func (apt) RemoveCommand(packages []string, purge bool) []string {
+	verb := "remove"
+	if purge {
+		verb = "purge"
+	}
+	return append([]string{"sudo", "apt-get", verb, "-y"}, packages...)
+}
+
+func (apt) AutoremoveCommand() []string {
+	return []string{"sudo", "apt-get", "autoremove", "-y"}
+}
+
+func (apt) Installed(pkg string) bool {
+	return utils.CheckCommand("dpkg", "-s", pkg)
+}
+
+func (apt) InstalledVersion(pkg string) string {
+	version, err := utils.CommandOutput("dpkg-query", "-W", "-f=${Version}", pkg)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// CandidateVersion returns the version apt would install, based on the
+// package lists from the last apt-get update
+func (apt) CandidateVersion(pkg string) (string, error) {
+	output, err := utils.CommandOutput("apt-cache", "policy", pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to query apt for %s: %w", pkg, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if candidate, ok := strings.CutPrefix(strings.TrimSpace(line), "Candidate:"); ok {
+			candidate = strings.TrimSpace(candidate)
+			if candidate != "" && candidate != "(none)" {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("apt has no candidate for %s", pkg)
+}
+
+// dnf manages packages on the RHEL family with dnf, or yum on older releases
+type dnf struct {
+	command string
+}
+
+func (d dnf) Name() string { return d.command }
+
+func (d dnf) UpdateCommand() []string {
+	return []string{"sudo", d.command, "makecache", "-y"}
+}
+
+func (d dnf) InstallCommand(packages []string) []string {
+	return append([]string{"sudo", d.command, "install", "-y"}, packages...)
+}
+
+func (d dnf) UpgradeCommand(packages []string) []string {
+	return append([]string{"sudo", d.command, "upgrade", "-y"}, packages...)
+}
+
+// RemoveCommand removes packages; rpm keeps no separate configuration to purge
+func (d dnf) RemoveCommand(packages []string, purge bool) []string {
+	return append([]string{"sudo", d.command, "remove", "-y"}, packages...)
+}
+
+func (d dnf) AutoremoveCommand() []string {
+	return []string{"sudo", d.command, "autoremove", "-y"}
+}
+
+func (dnf) Installed(pkg string) bool {
+	return utils.CheckCommand("rpm", "-q", pkg)
+}
+
+func (dnf) InstalledVersion(pkg string) string {
+	version, err := utils.CommandOutput("rpm", "-q", "--qf", "%{VERSION}", pkg)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+func (d dnf) CandidateVersion(pkg string) (string, error) {
+	output, err := utils.CommandOutput(d.command, "-q", "info", pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to query %s for %s: %w", d.command, pkg, err)
+	}
+	// Installed packages are listed before available ones, so the last
+	// version is the newest
+	version := ""
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "Version" {
+			version = strings.TrimSpace(value)
+		}
+	}
+	if version == "" {
+		return "", fmt.Errorf("%s has no candidate for %s", d.command, pkg)
+	}
+	return version, nil
+}
+
+// apk manages packages on Alpine
+type apk struct{}
+
+func (apk) Name() string { return "apk" }
+
+func (apk) UpdateCommand() []string {
+	return []string{"sudo", "apk", "update"}
+}
+
+func (apk) InstallCommand(packages []string) []string {
+	return append([]string{"sudo", "apk", "add"}, packages...)
+}
+
+func (apk) UpgradeCommand(packages []string) []string {
+	return append([]string{"sudo", "apk", "add", "--upgrade"}, packages...)
+}
+
+// RemoveCommand removes packages along with the dependencies they pulled in
+func (apk) RemoveCommand(packages []string, purge bool) []string {
+	args := []string{"sudo", "apk", "del"}
+	if purge {
+		args = append(args, "--purge")
+	}
+	return append(args, packages...)
+}
+
+func (apk) AutoremoveCommand() []string { return nil }
+
+func (apk) Installed(pkg string) bool {
+	return utils.CheckCommand("apk", "info", "-e", pkg)
+}
+
+func (apk) InstalledVersion(pkg string) string {
+	return apkListVersion(pkg, "list", "--installed", pkg)
+}
+
+func (apk) CandidateVersion(pkg string) (string, error) {
+	if version := apkListVersion(pkg, "list", pkg); version != "" {
+		return version, nil
+	}
+	return "", fmt.Errorf("apk has no candidate for %s", pkg)
+}
+
+// apkListVersion reads the version from `apk list` output such as
+// "redis-7.2.5-r0 x86_64 {redis} (BSD-3-Clause) [installed]"
+func apkListVersion(pkg string, args ...string) string {
+	output, err := utils.CommandOutput("apk", args...)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if version, ok := strings.CutPrefix(fields[0], pkg+"-"); ok {
+			return version
+		}
+	}
+	return ""
+}
+
+// pacman manages packages on Arch Linux
+type pacman struct{}
+
+func (pacman) Name() string { return "pacman" }
+
+// UpdateCommand upgrades the whole system along with the package lists,
+// since Arch does not support installing against refreshed lists alone
+func (pacman) UpdateCommand() []string {
+	return []string{"sudo", "pacman", "-Syu", "--noconfirm"}
+}
+
+func (pacman) InstallCommand(packages []string) []string {
+	return append([]string{"sudo", "pacman", "-S", "--needed", "--noconfirm"}, packages...)
+}
+
+func (pacman) UpgradeCommand(packages []string) []string {
+	return append([]string{"sudo", "pacman", "-S", "--noconfirm"}, packages...)
+}
+
+// RemoveCommand removes packages and the dependencies nothing else needs,
+// with purge also deleting their saved configuration
+func (pacman) RemoveCommand(packages []string, purge bool) []string {
+	flags := "-Rs"
+	if purge {
+		flags = "-Rns"
+	}
+	return append([]string{"sudo", "pacman", flags, "--noconfirm"}, packages...)
+}
+
+func (pacman) AutoremoveCommand() []string { return nil }
+
+func (pacman) Installed(pkg string) bool {
+	return utils.CheckCommand("pacman", "-Q", pkg)
+}
+
+func (pacman) InstalledVersion(pkg string) string {
+	output, err := utils.CommandOutput("pacman", "-Q", pkg)
+	if err != nil {
+		return ""
+	}
+	if fields := strings.Fields(output); len(fields) == 2 {
+		return fields[1]
+	}
+	return ""
+}
+
+func (pacman) CandidateVersion(pkg string) (string, error) {
+	output, err := utils.CommandOutput("pacman", "-Si", pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to query pacman for %s: %w", pkg, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "Version" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("pacman has no candidate for %s", pkg)
+}
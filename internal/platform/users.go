@@ -0,0 +1,22 @@
+package platform
+
+// UserAddCommands returns the commands that create a system user without a
+// home directory or login shell, in a group of the same name. Alpine ships
+// the BusyBox adduser, which only creates that group when asked to.
+func (o *OS) UserAddCommands(user string) [][]string {
+	if o.Family == Alpine {
+		return [][]string{
+			{"sudo", "addgroup", "-S", user},
+			{"sudo", "adduser", "-S", "-D", "-H", "-s", "/sbin/nologin", "-G", user, user},
+		}
+	}
+	return [][]string{{"sudo", "useradd", "--system", "--no-create-home", "--shell", "/usr/sbin/nologin", user}}
+}
+
+// UserDelCommand returns the command that deletes a user
+func (o *OS) UserDelCommand(user string) []string {
+	if o.Family == Alpine {
+		return []string{"sudo", "deluser", user}
+	}
+	return []string{"sudo", "userdel", user}
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// DefaultPath is where bootup keeps the credentials it generated. Unlike
+// the state file it is only readable by root.
+const DefaultPath = "/var/lib/bootup/secrets.json"
+
+// Path is the secrets file in use; BOOTUP_SECRETS_FILE overrides the default
+var Path = secretsFilePath()
+
+// Credentials are the generated values of one service, e.g. user and password
+type Credentials map[string]string
+
+// Secrets is the full content of the secrets file
+type Secrets struct {
+	Services map[string]Credentials `json:"services"`
+}
+
+func secretsFilePath() string {
+	if path := os.Getenv("BOOTUP_SECRETS_FILE"); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Load reads the secrets file, returning no secrets when it does not exist
+// yet. The file belongs to root, so other users read it through sudo.
+func Load() (*Secrets, error) {
+	s := &Secrets{Services: make(map[string]Credentials)}
+
+	data, err := os.ReadFile(Path)
+	if errors.Is(err, fs.ErrPermission) {
+		var output string
+		output, err = utils.CommandOutput("sudo", "cat", Path)
+		data = []byte(output)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file %s: %w", Path, err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %w", Path, err)
+	}
+	if s.Services == nil {
+		s.Services = make(map[string]Credentials)
+	}
+	return s, nil
+}
+
+// Save writes the secrets file through the active executor, readable by
+// root only
+func (s *Secrets) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.RunCommand("sudo", "mkdir", "-p", filepath.Dir(Path)); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	return utils.WriteFile(Path, string(data)+"\n", 0600)
+}
+
+// Get returns the credentials of a service
+func (s *Secrets) Get(service string) (Credentials, bool) {
+	credentials, ok := s.Services[service]
+	return credentials, ok
+}
+
+// Set stores the credentials of a service
+func (s *Secrets) Set(service string, credentials Credentials) {
+	s.Services[service] = credentials
+}
+
+// Remove forgets the credentials of a service
+func (s *Secrets) Remove(service string) {
+	delete(s.Services, service)
+}
+
+// Names returns the services with credentials in alphabetical order
+func (s *Secrets) Names() []string {
+	names := make([]string, 0, len(s.Services))
+	for name := range s.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// alphabet leaves out symbols so generated values can be used in URLs,
+// config files and command lines without escaping
+const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// Generate returns a random string of the given length, drawn from
+// crypto/rand without modulo bias
+func Generate(length int) string {
+	// The largest multiple of len(alphabet) that fits in a byte
+	limit := 256 - 256%len(alphabet)
+	result := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(result) < length {
+		// crypto/rand.Read never returns an error
+		rand.Read(buf)
+		for _, b := range buf {
+			if int(b) < limit && len(result) < length {
+				result = append(result, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(result)
+}
@@ -2,60 +2,202 @@ package services
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/amirkh8006/bootup-cli/internal/secrets"
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
-func InstallAlertmanager() error {
-	utils.PrintInfo("Installing Prometheus Alertmanager...")
+const (
+	alertmanagerUser       = "prometheus"
+	alertmanagerDir        = "/opt/alertmanager"
+	alertmanagerDataDir    = "/var/lib/alertmanager"
+	alertmanagerConfigDir  = "/etc/alertmanager"
+	alertmanagerConfigFile = "/etc/alertmanager/alertmanager.yml"
+	alertmanagerTarball    = "/tmp/alertmanager.tar.gz"
+)
+
+// alertmanagerReceiverKeys are the receiver settings AlertmanagerPlan and
+// `bootup configure alertmanager` understand, next to port
+var alertmanagerReceiverKeys = []string{
+	"email_to", "email_from", "smtp_smarthost", "smtp_auth_username", "smtp_auth_password", "smtp_require_tls",
+	"slack_api_url", "slack_channel",
+	"webhook_url",
+	"pagerduty_routing_key", "pagerduty_url",
+}
+
+// alertmanagerSecretKeys are kept in the secrets file rather than the
+// state file, which any user can read
+var alertmanagerSecretKeys = []string{"smtp_auth_password", "slack_api_url", "pagerduty_routing_key"}
+
+// alertmanagerSettings are the port and receivers rendered into the
+// Alertmanager unit and configuration
+type alertmanagerSettings struct {
+	port     int
+	receiver map[string]string // receiver settings that were given, by key
+}
+
+// newAlertmanagerSettings validates the receiver settings returned by get.
+// Each receiver is enabled by its address setting: email_to, slack_api_url,
+// webhook_url or pagerduty_routing_key.
+func newAlertmanagerSettings(get func(key, fallback string) string, port int) (alertmanagerSettings, error) {
+	s := alertmanagerSettings{port: port, receiver: make(map[string]string)}
+	for _, key := range alertmanagerReceiverKeys {
+		if value := get(key, ""); value != "" {
+			if strings.ContainsAny(value, "\r\n") {
+				return s, fmt.Errorf("alertmanager %s must be a single line", key)
+			}
+			s.receiver[key] = value
+		}
+	}
+	r := s.receiver
 
-	// Define version and directories
-	alertmanagerVersion := "0.28.1"
-	alertmanagerUser := "prometheus"
-	alertmanagerDir := "/opt/alertmanager"
-	alertmanagerDataDir := "/var/lib/alertmanager"
-	alertmanagerConfigFile := "/etc/alertmanager/alertmanager.yml"
-	alertmanagerTarball := "/tmp/alertmanager.tar.gz"
+	if r["email_to"] != "" || r["email_from"] != "" || r["smtp_smarthost"] != "" {
+		for _, key := range []string{"email_to", "email_from", "smtp_smarthost"} {
+			if r[key] == "" {
+				return s, fmt.Errorf("alertmanager email alerts need email_to, email_from and smtp_smarthost, %s is missing", key)
+			}
+		}
+		if _, err := mail.ParseAddressList(r["email_to"]); err != nil {
+			return s, fmt.Errorf("alertmanager email_to %q: %w", r["email_to"], err)
+		}
+		if _, err := mail.ParseAddress(r["email_from"]); err != nil {
+			return s, fmt.Errorf("alertmanager email_from %q: %w", r["email_from"], err)
+		}
+		if _, _, err := net.SplitHostPort(r["smtp_smarthost"]); err != nil {
+			return s, fmt.Errorf("alertmanager smtp_smarthost %q is not host:port", r["smtp_smarthost"])
+		}
+	} else {
+		for _, key := range []string{"smtp_auth_username", "smtp_auth_password", "smtp_require_tls"} {
+			if r[key] != "" {
+				return s, fmt.Errorf("alertmanager %s needs email_to, email_from and smtp_smarthost", key)
+			}
+		}
+	}
+	if r["smtp_require_tls"] != "" {
+		if _, err := strconv.ParseBool(r["smtp_require_tls"]); err != nil {
+			return s, fmt.Errorf("alertmanager smtp_require_tls %q is not true or false", r["smtp_require_tls"])
+		}
+	}
 
-	// Create prometheus user if not exists (reuse prometheus user)
-	utils.PrintInfo("Checking prometheus user...")
-	checkUserCmd := fmt.Sprintf("id %s", alertmanagerUser)
-	if err := utils.RunCommandShell(checkUserCmd); err != nil {
-		utils.PrintInfo("Creating prometheus user...")
-		createUserCmd := fmt.Sprintf("sudo useradd --no-create-home --shell /usr/sbin/nologin %s", alertmanagerUser)
-		if err := utils.RunCommandShell(createUserCmd); err != nil {
-			return fmt.Errorf("failed to create prometheus user: %w", err)
+	if r["slack_channel"] != "" && r["slack_api_url"] == "" {
+		return s, fmt.Errorf("alertmanager slack_channel needs slack_api_url")
+	}
+	if r["pagerduty_url"] != "" && r["pagerduty_routing_key"] == "" {
+		return s, fmt.Errorf("alertmanager pagerduty_url needs pagerduty_routing_key")
+	}
+	for _, key := range []string{"slack_api_url", "webhook_url", "pagerduty_url"} {
+		if r[key] == "" {
+			continue
+		}
+		u, err := url.Parse(r[key])
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			// The value may hold a token, keep it out of the error
+			return s, fmt.Errorf("alertmanager %s is not an http or https URL", key)
 		}
 	}
+	return s, nil
+}
 
-	// Download Alertmanager
-	utils.PrintInfo(fmt.Sprintf("Downloading Alertmanager %s...", alertmanagerVersion))
-	downloadUrl := fmt.Sprintf("https://github.com/prometheus/alertmanager/releases/download/v%s/alertmanager-%s.linux-amd64.tar.gz", alertmanagerVersion, alertmanagerVersion)
-	downloadCmd := fmt.Sprintf("wget '%s' -O %s", downloadUrl, alertmanagerTarball)
-	if err := utils.RunCommandShell(downloadCmd); err != nil {
-		return fmt.Errorf("failed to download Alertmanager: %w", err)
+// config returns the settings as recorded in the state file, without the
+// secret ones
+func (s alertmanagerSettings) config() map[string]string {
+	config := map[string]string{
+		"config_file":    alertmanagerConfigFile,
+		"data_dir":       alertmanagerDataDir,
+		"listen_address": fmt.Sprintf(":%d", s.port),
+		"port":           strconv.Itoa(s.port),
+		"user":           alertmanagerUser,
+	}
+	for key, value := range s.receiver {
+		if !slices.Contains(alertmanagerSecretKeys, key) {
+			config[key] = value
+		}
 	}
+	return config
+}
 
-	// Create and extract to directory
-	utils.PrintInfo("Extracting Alertmanager...")
-	if err := utils.RunCommandShell(fmt.Sprintf("sudo mkdir -p %s", alertmanagerDir)); err != nil {
-		return fmt.Errorf("failed to create alertmanager directory: %w", err)
+// secrets returns the secret settings, to be kept in the secrets file
+func (s alertmanagerSettings) secrets() secrets.Credentials {
+	values := make(secrets.Credentials)
+	for _, key := range alertmanagerSecretKeys {
+		if value := s.receiver[key]; value != "" {
+			values[key] = value
+		}
 	}
+	return values
+}
 
-	extractCmd := fmt.Sprintf("sudo tar -xzf %s --strip-components=1 -C %s", alertmanagerTarball, alertmanagerDir)
-	if err := utils.RunCommandShell(extractCmd); err != nil {
-		return fmt.Errorf("failed to extract Alertmanager: %w", err)
+// receivers names the receivers that are set up
+func (s alertmanagerSettings) receivers() []string {
+	var names []string
+	for key, name := range map[string]string{
+		"email_to":              "email",
+		"slack_api_url":         "Slack",
+		"webhook_url":           "webhook",
+		"pagerduty_routing_key": "PagerDuty",
+	} {
+		if s.receiver[key] != "" {
+			names = append(names, name)
+		}
 	}
+	slices.Sort(names)
+	return names
+}
+
+// yamlQuote quotes a value as a single-quoted YAML string
+func yamlQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
 
-	// Create data and config directories
-	utils.PrintInfo("Creating configuration directories...")
-	if err := utils.RunCommandShell(fmt.Sprintf("sudo mkdir -p %s /etc/alertmanager", alertmanagerDataDir)); err != nil {
-		return fmt.Errorf("failed to create alertmanager directories: %w", err)
+// alertmanagerConfig renders alertmanager.yml with a single receiver that
+// sends to every configured integration. Without any, alerts are accepted
+// and shown in the web UI but not sent anywhere.
+func alertmanagerConfig(s alertmanagerSettings) string {
+	r := s.receiver
+	var receiver strings.Builder
+	if r["email_to"] != "" {
+		requireTLS := "true"
+		if r["smtp_require_tls"] != "" {
+			tls, _ := strconv.ParseBool(r["smtp_require_tls"])
+			requireTLS = strconv.FormatBool(tls)
+		}
+		fmt.Fprintf(&receiver, "    email_configs:\n      - to: %s\n        from: %s\n        smarthost: %s\n",
+			yamlQuote(r["email_to"]), yamlQuote(r["email_from"]), yamlQuote(r["smtp_smarthost"]))
+		if r["smtp_auth_username"] != "" {
+			fmt.Fprintf(&receiver, "        auth_username: %s\n", yamlQuote(r["smtp_auth_username"]))
+		}
+		if r["smtp_auth_password"] != "" {
+			fmt.Fprintf(&receiver, "        auth_password: %s\n", yamlQuote(r["smtp_auth_password"]))
+		}
+		fmt.Fprintf(&receiver, "        require_tls: %s\n        send_resolved: true\n", requireTLS)
+	}
+	if r["slack_api_url"] != "" {
+		fmt.Fprintf(&receiver, "    slack_configs:\n      - api_url: %s\n", yamlQuote(r["slack_api_url"]))
+		if r["slack_channel"] != "" {
+			fmt.Fprintf(&receiver, "        channel: %s\n", yamlQuote(r["slack_channel"]))
+		}
+		receiver.WriteString("        send_resolved: true\n")
+	}
+	if r["webhook_url"] != "" {
+		fmt.Fprintf(&receiver, "    webhook_configs:\n      - url: %s\n", yamlQuote(r["webhook_url"]))
+	}
+	if r["pagerduty_routing_key"] != "" {
+		fmt.Fprintf(&receiver, "    pagerduty_configs:\n      - routing_key: %s\n", yamlQuote(r["pagerduty_routing_key"]))
+		if r["pagerduty_url"] != "" {
+			fmt.Fprintf(&receiver, "        url: %s\n", yamlQuote(r["pagerduty_url"]))
+		}
 	}
 
-	// Create default configuration
-	utils.PrintInfo("Creating default configuration...")
-	configContent := `global:
+	return fmt.Sprintf(`global:
   resolve_timeout: 5m
 
 route:
@@ -63,28 +205,29 @@ route:
   group_wait: 10s
   group_interval: 10s
   repeat_interval: 1h
-  receiver: 'web.hook'
+  receiver: 'bootup'
 
 receivers:
-  - name: 'web.hook'
-    webhook_configs:
-      - url: 'http://127.0.0.1:5001/'
-
+  - name: 'bootup'
+%s
 inhibit_rules:
   - source_match:
       severity: 'critical'
     target_match:
       severity: 'warning'
-    equal: ['alertname', 'dev', 'instance']`
+    equal: ['alertname', 'dev', 'instance']
+`, receiver.String())
+}
 
-	createConfigCmd := fmt.Sprintf("sudo tee %s > /dev/null <<'EOL'\n%s\nEOL", alertmanagerConfigFile, configContent)
-	if err := utils.RunCommandShell(createConfigCmd); err != nil {
-		return fmt.Errorf("failed to create alertmanager config: %w", err)
-	}
+// alertmanagerCandidate is where a new configuration is written for amtool
+// to check before it replaces alertmanager.yml
+func alertmanagerCandidate() string {
+	return filepath.Join(os.TempDir(), "bootup-alertmanager.yml")
+}
 
-	// Create systemd service
-	utils.PrintInfo("Creating systemd service...")
-	serviceContent := fmt.Sprintf(`[Unit]
+// alertmanagerUnit renders the systemd unit
+func alertmanagerUnit(s alertmanagerSettings) string {
+	return fmt.Sprintf(`[Unit]
 Description=Prometheus Alertmanager
 Wants=network-online.target
 After=network-online.target
@@ -97,46 +240,215 @@ ExecStart=%s/alertmanager \
   --config.file=%s \
   --storage.path=%s \
   --cluster.listen-address="" \
-  --web.listen-address=:9094
+  --web.listen-address=:%d
 
 Restart=always
 
 [Install]
-WantedBy=multi-user.target`, alertmanagerUser, alertmanagerUser, alertmanagerDir, alertmanagerConfigFile, alertmanagerDataDir)
+WantedBy=multi-user.target
+`, alertmanagerUser, alertmanagerUser, alertmanagerDir, alertmanagerConfigFile, alertmanagerDataDir, s.port)
+}
+
+// alertmanagerReceiversNote tells the user where alerts go
+func alertmanagerReceiversNote(s alertmanagerSettings) string {
+	if receivers := s.receivers(); len(receivers) > 0 {
+		return "Alerts are sent by " + strings.Join(receivers, ", ")
+	}
+	return "No receiver is set up, add one with: bootup configure alertmanager --set email_to=... (see the README for the settings)"
+}
+
+// alertmanagerAddress is the address Prometheus sends alerts to
+func alertmanagerAddress(port int) string {
+	return net.JoinHostPort("localhost", strconv.Itoa(port))
+}
+
+// installedAlertmanagerAddress returns the address of an installed
+// Alertmanager, or "" when there is none
+func installedAlertmanagerAddress() string {
+	if !IsServiceInstalled("alertmanager") {
+		return ""
+	}
+	for _, port := range installedPorts("alertmanager") {
+		if port.Name == "port" {
+			return alertmanagerAddress(port.Number)
+		}
+	}
+	return ""
+}
+
+// prometheusAlertingSteps rewrite prometheus.yml to send alerts to the
+// given Alertmanager, or to none, and reload Prometheus. A Prometheus that
+// bootup did not install is left alone.
+func prometheusAlertingSteps(description, alertmanager string) []Step {
+	if _, ok := GetInstallRecord("prometheus"); !ok {
+		return nil
+	}
+	s := installedPrometheusSettings()
+	s.alertmanager = alertmanager
+	return []Step{
+		writeFile(description, prometheusConfigFile, prometheusConfig(s), 0644),
+		serviceCommand("Reloading Prometheus", "reload", "prometheus").optional(),
+	}
+}
 
-	createServiceCmd := fmt.Sprintf("sudo tee /etc/systemd/system/alertmanager.service > /dev/null <<'EOL'\n%s\nEOL", serviceContent)
-	if err := utils.RunCommandShell(createServiceCmd); err != nil {
-		return fmt.Errorf("failed to create systemd service: %w", err)
+// AlertmanagerPlan builds the install plan for Prometheus Alertmanager
+func AlertmanagerPlan() (*Plan, error) {
+	release := selectRelease("alertmanager")
+	owner := alertmanagerUser + ":" + alertmanagerUser
+	port, err := servicePort("alertmanager", "port")
+	if err != nil {
+		return nil, err
 	}
+	settings, err := newAlertmanagerSettings(func(key, fallback string) string {
+		return setting("alertmanager", key, fallback)
+	}, port)
+	if err != nil {
+		return nil, err
+	}
+
+	config := alertmanagerConfig(settings)
+	candidate := alertmanagerCandidate()
 
-	// Fix permissions
-	utils.PrintInfo("Setting permissions...")
-	chownCmd := fmt.Sprintf("sudo chown -R %s:%s %s %s /etc/alertmanager", alertmanagerUser, alertmanagerUser, alertmanagerDir, alertmanagerDataDir)
-	if err := utils.RunCommandShell(chownCmd); err != nil {
-		return fmt.Errorf("failed to set permissions: %w", err)
+	plan := NewPlan("alertmanager")
+	plan.Version = release.Version
+	plan.Config = settings.config()
+	plan.Add(
+		// Reuses the prometheus user when it already exists
+		createUser(alertmanagerUser),
+		release.download(fmt.Sprintf("Downloading Alertmanager %s", release.Version), alertmanagerTarball),
+		extract("Extracting Alertmanager", alertmanagerTarball, alertmanagerDir, 1),
+		directory("Creating data directory", alertmanagerDataDir, owner),
+		// The configuration file is only readable once chowned below, so
+		// amtool checks a copy
+		writeFile("Writing the configuration for checking", candidate, config, 0600),
+		verify("Checking configuration", filepath.Join(alertmanagerDir, "amtool"), "check-config", candidate),
+		command("Removing the checked configuration", "rm", "-f", candidate).optional(),
+		directory("Creating configuration directory", alertmanagerConfigDir, ""),
+		// Readable by the service user only, receivers may carry credentials
+		writeFile("Creating default configuration", alertmanagerConfigFile, config, 0640),
+		systemdUnit("alertmanager", alertmanagerUnit(settings)),
+		command("Setting permissions", "sudo", "chown", "-R", owner, alertmanagerDir, alertmanagerConfigDir),
+		enableStart("alertmanager"),
+		command("Cleaning up downloaded archive", "rm", "-f", alertmanagerTarball).optional(),
+	)
+	if values := settings.secrets(); len(values) > 0 {
+		plan.Add(action("Storing receiver credentials", func() error {
+			return storeSecretSettings("alertmanager", values)
+		}))
 	}
+	if IsServiceInstalled("prometheus") {
+		plan.Add(prometheusAlertingSteps("Pointing Prometheus at Alertmanager", alertmanagerAddress(port))...)
+	}
+	plan.Success = "Prometheus Alertmanager installed and running!"
+	plan.Note(
+		fmt.Sprintf("Alertmanager is accessible at http://localhost:%d", port),
+		alertmanagerReceiversNote(settings),
+	)
+	return plan, nil
+}
 
-	// Enable and start service
-	utils.PrintInfo("Starting Alertmanager service...")
-	if err := utils.RunCommand("sudo", "systemctl", "daemon-reload"); err != nil {
-		return fmt.Errorf("failed to reload systemd: %w", err)
+// AlertmanagerConfigurePlan rewrites the configuration and unit of an
+// installed Alertmanager with the given settings changed. The new
+// configuration is checked with amtool before anything is replaced, and if
+// Alertmanager does not come back healthy the previous files are restored
+// and it is restarted with them.
+func AlertmanagerConfigurePlan(changes map[string]string) (*Plan, error) {
+	for key := range changes {
+		if key != "port" && !slices.Contains(alertmanagerReceiverKeys, key) {
+			return nil, fmt.Errorf("alertmanager has no setting %s, its settings are port, %s", key, strings.Join(alertmanagerReceiverKeys, ", "))
+		}
+	}
+	record, ok := GetInstallRecord("alertmanager")
+	if !ok {
+		return nil, fmt.Errorf("alertmanager was not installed by bootup, so its settings are unknown")
 	}
+	stored, err := secretSettings("alertmanager")
+	if err != nil {
+		return nil, err
+	}
+	current := maps.Clone(record.Config)
+	maps.Copy(current, stored)
 
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "alertmanager"); err != nil {
-		return fmt.Errorf("failed to enable alertmanager service: %w", err)
+	get := func(key, fallback string) string {
+		if value, ok := changes[key]; ok {
+			// An empty value resets the setting to its default
+			if value == "" {
+				return fallback
+			}
+			return value
+		}
+		if value := current[key]; value != "" {
+			return value
+		}
+		return fallback
+	}
+	port, err := parsePort(get("port", "9094"))
+	if err != nil {
+		return nil, fmt.Errorf("alertmanager port: %w", err)
+	}
+	settings, err := newAlertmanagerSettings(get, port)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := utils.RunCommand("sudo", "systemctl", "start", "alertmanager"); err != nil {
-		return fmt.Errorf("failed to start alertmanager service: %w", err)
+	owner := alertmanagerUser + ":" + alertmanagerUser
+	candidate := alertmanagerCandidate()
+	config := alertmanagerConfig(settings)
+	healthy := func() error {
+		return httpCheck(localAddress(settings.port), "/-/healthy")
 	}
 
-	// Clean up downloaded tarball
-	if err := utils.RunCommandShell(fmt.Sprintf("rm -f %s", alertmanagerTarball)); err != nil {
-		return fmt.Errorf("failed to remove alertmanager tarball: %w", err)
+	plan := NewPlan("alertmanager")
+	plan.Config = settings.config()
+	plan.Add(
+		writeFile("Writing the new configuration for checking", candidate, config, 0600),
+		verify("Checking the new configuration", filepath.Join(alertmanagerDir, "amtool"), "check-config", candidate),
+		command("Removing the checked configuration", "rm", "-f", candidate).optional(),
+		// Rolling this back runs last, once the unit was restored as well
+		writeFile("Writing configuration", alertmanagerConfigFile, config, 0640).withAfterUndo(func() error {
+			utils.PrintInfo("Restarting Alertmanager with the previous configuration...")
+			return restartService("alertmanager").Apply()
+		}),
+		command("Setting permissions", "sudo", "chown", owner, alertmanagerConfigFile),
+		systemdUnit("alertmanager", alertmanagerUnit(settings)),
+		restartService("alertmanager"),
+		waitHealthy("Waiting for Alertmanager to become healthy", healthy),
+		action("Storing receiver credentials", func() error {
+			return storeSecretSettings("alertmanager", settings.secrets())
+		}),
+	)
+	if strconv.Itoa(settings.port) != record.Config["port"] {
+		plan.Add(prometheusAlertingSteps("Pointing Prometheus at the new Alertmanager port", alertmanagerAddress(settings.port))...)
 	}
+	plan.Success = "Alertmanager reconfigured and running!"
+	plan.Note(alertmanagerReceiversNote(settings))
+	return plan, nil
+}
 
-	utils.PrintSuccess("Prometheus Alertmanager installed and running!")
-	utils.PrintInfo("Alertmanager is accessible at http://localhost:9094")
+// AlertmanagerUninstallPlan builds the removal plan for Alertmanager
+func AlertmanagerUninstallPlan(purge bool) (*Plan, error) {
+	spec := uninstallSpec{
+		units:     []string{"alertmanager"},
+		unitFiles: []string{"alertmanager"},
+		files:     []string{alertmanagerDir},
+		dataDirs:  []string{alertmanagerDataDir, alertmanagerConfigDir},
+	}
+	// The prometheus user is shared with Prometheus itself
+	if !IsServiceInstalled("prometheus") {
+		spec.users = []string{alertmanagerUser}
+	}
+	plan := uninstallPlan("alertmanager", spec, purge)
+	plan.Add(prometheusAlertingSteps("Removing Alertmanager from Prometheus", "")...)
+	return plan, nil
+}
 
-	return nil
+// AlertmanagerUpgradePlan replaces the Alertmanager binaries with another release
+func AlertmanagerUpgradePlan(version string) (*Plan, error) {
+	return tarballUpgradePlan(tarballUpgrade{
+		service: "alertmanager",
+		title:   "Alertmanager",
+		unit:    "alertmanager",
+		dir:     alertmanagerDir,
+		owner:   alertmanagerUser + ":" + alertmanagerUser,
+	}, version), nil
 }
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/amirkh8006/bootup-cli/internal/secrets"
+	"github.com/amirkh8006/bootup-cli/internal/state"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// CanConfigure reports whether a service supports `bootup configure`
+func CanConfigure(serviceName string) bool {
+	service, exists := serviceRegistry[serviceName]
+	return exists && service.Configure != nil
+}
+
+// ConfiguredSettings returns the settings an installed service was installed
+// or last configured with
+func ConfiguredSettings(serviceName string) (map[string]string, error) {
+	if !CanConfigure(serviceName) {
+		return nil, fmt.Errorf("bootup cannot configure %s", serviceName)
+	}
+	record, ok := GetInstallRecord(serviceName)
+	if !ok {
+		return nil, fmt.Errorf("%s was not installed by bootup", serviceName)
+	}
+	if len(secretSettingKeys[serviceName]) == 0 {
+		return record.Config, nil
+	}
+
+	// Only show that a secret is set, not its value
+	stored, err := secretSettings(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	maps.Copy(settings, record.Config)
+	for _, key := range secretSettingKeys[serviceName] {
+		if stored[key] != "" {
+			settings[key] = "(set, stored in " + secrets.Path + ")"
+		}
+	}
+	return settings, nil
+}
+
+// ConfigureService changes settings of an installed service and restarts
+// it, then records the new settings in the state file
+func ConfigureService(serviceName string, changes map[string]string) error {
+	if !CanConfigure(serviceName) {
+		return fmt.Errorf("bootup cannot configure %s", serviceName)
+	}
+	if !IsServiceInstalled(serviceName) {
+		return fmt.Errorf("%s is not installed", serviceName)
+	}
+
+	plan, err := serviceRegistry[serviceName].Configure(changes)
+	if err != nil {
+		return err
+	}
+	if err := plan.Execute(); err != nil {
+		return err
+	}
+
+	recordConfig(serviceName, plan.Config)
+	return nil
+}
+
+// recordConfig stores the settings a service was reconfigured with in the
+// state file
+func recordConfig(serviceName string, config map[string]string) {
+	s, err := state.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update install state: %v", err))
+		return
+	}
+	record, ok := s.Get(serviceName)
+	if !ok {
+		return
+	}
+
+	record.Config = config
+	s.Set(record)
+	if err := s.Save(); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update install state: %v", err))
+	}
+}
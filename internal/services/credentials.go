@@ -0,0 +1,328 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/secrets"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+const (
+	// passwordLength is the length of generated passwords, about 143 bits
+	passwordLength = 24
+
+	// seaweedfsS3Config holds the S3 identity SeaweedFS accepts
+	seaweedfsS3Config = "/etc/seaweedfs/s3.json"
+)
+
+// credentialSpec describes the credentials bootup generates for a service
+// and how to apply them to it
+type credentialSpec struct {
+	generate func() secrets.Credentials
+	apply    func(secrets.Credentials) []Step
+	restart  string // unit to restart after rotating, for services that read them at startup
+}
+
+// credentialRegistry lists the services installed with generated credentials
+// instead of well-known defaults
+var credentialRegistry = map[string]credentialSpec{
+	"rabbitmq":   {generate: adminPassword, apply: rabbitmqCredentials},
+	"grafana":    {generate: adminPassword, apply: grafanaCredentials},
+	"postgresql": {generate: postgresPassword, apply: postgresCredentials},
+	"seaweedfs":  {generate: s3Keys, apply: seaweedfsCredentials, restart: "seaweedfs"},
+	"rustfs":     {generate: s3Keys, apply: rustfsCredentials, restart: "rustfs"},
+}
+
+func adminPassword() secrets.Credentials {
+	return secrets.Credentials{"user": "admin", "password": secrets.Generate(passwordLength)}
+}
+
+func postgresPassword() secrets.Credentials {
+	password := secrets.Generate(passwordLength)
+	return secrets.Credentials{
+		"user":     "postgres",
+		"password": password,
+		"dsn":      fmt.Sprintf("postgresql://postgres:%s@%s/postgres?sslmode=disable", password, postgresAddress()),
+	}
+}
+
+// postgresAddress is the address of the local PostgreSQL, on the port it
+// was recorded with or the distribution's default
+func postgresAddress() string {
+	for _, port := range installedPorts("postgresql") {
+		if port.Name == "port" {
+			return net.JoinHostPort("localhost", strconv.Itoa(port.Number))
+		}
+	}
+	return "localhost:5432"
+}
+
+func s3Keys() secrets.Credentials {
+	return secrets.Credentials{"access_key": secrets.Generate(20), "secret_key": secrets.Generate(40)}
+}
+
+// secretInputFile holds a secret while a command reads it on stdin, so it
+// never appears in the command line other users can see with ps. It lives
+// next to the secrets file, in a directory only root can write to.
+func secretInputFile(serviceName string) string {
+	return filepath.Join(filepath.Dir(secrets.Path), serviceName+".input")
+}
+
+// withSecretInput writes input to a root-only file and runs the shell
+// commands as root with it on stdin, one command per step
+func withSecretInput(serviceName, input string, commands ...Step) []Step {
+	file := secretInputFile(serviceName)
+	steps := []Step{
+		directory("Creating secrets directory", filepath.Dir(file), ""),
+		writeFile("Writing credentials for the service", file, input, 0600),
+	}
+	for _, step := range commands {
+		step.Shell = fmt.Sprintf("sudo sh -c '%s < %s'", step.Shell, file)
+		steps = append(steps, step)
+	}
+	return append(steps, command("Removing the written credentials", "sudo", "rm", "-f", file).optional())
+}
+
+func rabbitmqCredentials(credentials secrets.Credentials) []Step {
+	user := credentials["user"]
+	// rabbitmqctl reads the password from stdin when it is left out
+	steps := withSecretInput("rabbitmq", credentials["password"]+"\n",
+		shellCommand("Creating admin user", "rabbitmqctl add_user "+user).optional(),
+		// add_user fails when the user exists, e.g. after a reinstall that kept the data
+		shellCommand("Setting admin password", "rabbitmqctl change_password "+user),
+	)
+	return append(steps,
+		command("Setting admin user tags", "sudo", "rabbitmqctl", "set_user_tags", user, "administrator"),
+		command("Setting admin permissions", "sudo", "rabbitmqctl", "set_permissions", "-p", "/", user, ".*", ".*", ".*"),
+	)
+}
+
+func grafanaCredentials(credentials secrets.Credentials) []Step {
+	return withSecretInput("grafana", credentials["password"]+"\n",
+		shellCommand("Setting Grafana admin password",
+			"grafana-cli --homepath /usr/share/grafana admin reset-admin-password --password-from-stdin"),
+	)
+}
+
+func postgresCredentials(credentials secrets.Credentials) []Step {
+	steps := withSecretInput("postgresql", fmt.Sprintf("ALTER USER postgres PASSWORD '%s';\n", credentials["password"]),
+		shellCommand("Setting the postgres password", "sudo -u postgres psql -q -v ON_ERROR_STOP=1"),
+	)
+	// Keep an exporter that was installed with the generated DSN working
+	if record, ok := GetInstallRecord("postgres_exporter"); ok && record.Config["credentials"] == "postgresql" {
+		steps = append(steps,
+			writeFile("Updating Postgres Exporter DSN", exporterEnvFile("postgres_exporter"), postgresExporterEnvContent(credentials["dsn"]), 0600),
+			restartService("postgres_exporter"),
+		)
+	}
+	return steps
+}
+
+func seaweedfsCredentials(credentials secrets.Credentials) []Step {
+	config, _ := json.MarshalIndent(map[string]any{
+		"identities": []map[string]any{{
+			"name": "admin",
+			"credentials": []map[string]string{{
+				"accessKey": credentials["access_key"],
+				"secretKey": credentials["secret_key"],
+			}},
+			"actions": []string{"Admin", "Read", "List", "Tagging", "Write"},
+		}},
+	}, "", "  ")
+	return []Step{
+		directory("Creating SeaweedFS configuration directory", filepath.Dir(seaweedfsS3Config), ""),
+		writeFile("Writing SeaweedFS S3 credentials", seaweedfsS3Config, string(config)+"\n", 0600),
+	}
+}
+
+// rustfsCredentials rewrites the RustFS environment file with new keys and
+// the settings RustFS was installed with
+func rustfsCredentials(credentials secrets.Credentials) []Step {
+	return []Step{
+		writeFile("Writing RustFS configuration", rustfsEnvFile, rustfsEnvContent(installedRustFSSettings(), credentials), 0600),
+	}
+}
+
+func postgresExporterEnvContent(dsn string) string {
+	return fmt.Sprintf("DATA_SOURCE_NAME=%s\n", dsn)
+}
+
+// serviceCredentials returns the credentials a service is installed with:
+// the ones stored by an earlier install, so a reinstall keeps them, or new
+// ones
+func serviceCredentials(serviceName string) secrets.Credentials {
+	s, err := secrets.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to read stored credentials: %v", err))
+	} else if credentials, ok := s.Get(serviceName); ok {
+		return credentials
+	}
+	return credentialRegistry[serviceName].generate()
+}
+
+// credentialSteps applies the credentials of a service and stores them in
+// the secrets file
+func credentialSteps(serviceName string, credentials secrets.Credentials) []Step {
+	steps := credentialRegistry[serviceName].apply(credentials)
+	return append(steps, storeCredentialsStep(serviceName, credentials))
+}
+
+// storeCredentialsStep stores the credentials of a service in the secrets
+// file, for services that apply them as part of their own configuration
+func storeCredentialsStep(serviceName string, credentials secrets.Credentials) Step {
+	return action("Storing generated credentials", func() error {
+		return storeCredentials(serviceName, credentials)
+	})
+}
+
+// credentialsNote tells the user where to find the generated credentials
+func credentialsNote(serviceName string) string {
+	return fmt.Sprintf("Generated credentials are stored in %s, show them with: bootup credentials show %s",
+		secrets.Path, serviceName)
+}
+
+// secretSettingKeys lists the settings of a service kept in the secrets
+// file rather than the state file
+var secretSettingKeys = map[string][]string{
+	"alertmanager": alertmanagerSecretKeys,
+}
+
+// secretSettings returns the stored secret settings of a service
+func secretSettings(serviceName string) (secrets.Credentials, error) {
+	s, err := secrets.Load()
+	if err != nil {
+		return nil, err
+	}
+	values, _ := s.Get(serviceName)
+	return values, nil
+}
+
+// storeSecretSettings replaces the stored secret settings of a service,
+// dropping the entry when none are left
+func storeSecretSettings(serviceName string, values secrets.Credentials) error {
+	if len(values) > 0 {
+		return storeCredentials(serviceName, values)
+	}
+	s, err := secrets.Load()
+	if err != nil {
+		return err
+	}
+	if _, ok := s.Get(serviceName); !ok {
+		return nil
+	}
+	s.Remove(serviceName)
+	if err := s.Save(); err != nil {
+		return fmt.Errorf("failed to store credentials: %w", err)
+	}
+	return nil
+}
+
+func storeCredentials(serviceName string, credentials secrets.Credentials) error {
+	s, err := secrets.Load()
+	if err != nil {
+		return err
+	}
+	s.Set(serviceName, credentials)
+	if err := s.Save(); err != nil {
+		return fmt.Errorf("failed to store credentials: %w", err)
+	}
+	return nil
+}
+
+// HasCredentials reports whether bootup generates credentials for a service
+func HasCredentials(serviceName string) bool {
+	_, ok := credentialRegistry[serviceName]
+	return ok
+}
+
+// CredentialServices returns the services bootup generates credentials for
+func CredentialServices() []string {
+	names := make([]string, 0, len(credentialRegistry))
+	for name := range credentialRegistry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ShowCredentials returns the credentials bootup generated for a service
+func ShowCredentials(serviceName string) (secrets.Credentials, error) {
+	if !HasCredentials(serviceName) {
+		return nil, fmt.Errorf("bootup does not generate credentials for %s", serviceName)
+	}
+	s, err := secrets.Load()
+	if err != nil {
+		return nil, err
+	}
+	credentials, ok := s.Get(serviceName)
+	if !ok {
+		return nil, fmt.Errorf("no credentials are stored for %s, it was not installed by bootup", serviceName)
+	}
+	return credentials, nil
+}
+
+// RotateCredentials replaces the credentials of an installed service with
+// new ones, applies them and stores them
+func RotateCredentials(serviceName string) (secrets.Credentials, error) {
+	spec, ok := credentialRegistry[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("bootup does not generate credentials for %s", serviceName)
+	}
+	if !IsServiceInstalled(serviceName) {
+		return nil, fmt.Errorf("%s is not installed", serviceName)
+	}
+
+	credentials := spec.generate()
+	plan := NewPlan(serviceName)
+	plan.Add(spec.apply(credentials)...)
+	if spec.restart != "" {
+		plan.Add(restartService(spec.restart))
+	}
+	plan.Add(action("Storing new credentials", func() error {
+		return storeCredentials(serviceName, credentials)
+	}))
+	plan.Success = fmt.Sprintf("Credentials of %s rotated", serviceName)
+	if err := plan.Execute(); err != nil {
+		// Some steps may already use the new credentials, don't lose them
+		utils.PrintWarning(fmt.Sprintf("The new credentials may be partly applied: %s", formatCredentials(credentials)))
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// ForgetCredentials removes the credentials of an uninstalled service from
+// the secrets file
+func ForgetCredentials(serviceName string) {
+	if !HasCredentials(serviceName) && len(secretSettingKeys[serviceName]) == 0 {
+		return
+	}
+	s, err := secrets.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update stored credentials: %v", err))
+		return
+	}
+	if _, ok := s.Get(serviceName); !ok {
+		return
+	}
+
+	s.Remove(serviceName)
+	if err := s.Save(); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update stored credentials: %v", err))
+	}
+}
+
+// formatCredentials prints credentials as key=value pairs in a stable order
+func formatCredentials(credentials secrets.Credentials) string {
+	keys := slices.Sorted(maps.Keys(credentials))
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + credentials[key]
+	}
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// ResolveInstallOrder expands the requested services with their missing
+// prerequisites and returns them in the order they must be installed.
+// Requested services are always included; prerequisites only when they are
+// not installed yet.
+func ResolveInstallOrder(serviceNames []string) ([]string, error) {
+	requested := make(map[string]bool)
+	for _, name := range serviceNames {
+		if !IsValidService(name) {
+			return nil, fmt.Errorf("service %s is not supported", name)
+		}
+		requested[name] = true
+	}
+
+	var order []string
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		visiting[name] = true
+
+		for _, dep := range serviceRegistry[name].Requires {
+			if !IsValidService(dep) {
+				return fmt.Errorf("%s requires unknown service %s", name, dep)
+			}
+			if !requested[dep] && IsServiceInstalled(dep) {
+				continue
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		visiting[name] = false
+		visited[name] = true
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range serviceNames {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// InstallServices installs the given services and their missing
+// prerequisites in dependency order, stopping at the first failure
+func InstallServices(serviceNames []string) error {
+	order, err := ResolveInstallOrder(serviceNames)
+	if err != nil {
+		return err
+	}
+
+	// Refuse before anything is installed rather than after the
+	// prerequisites are
+	for _, name := range order {
+		if err := CheckSupported(name); err != nil {
+			return err
+		}
+	}
+	if err := preflightServices(order); err != nil {
+		return err
+	}
+
+	if len(order) > 1 {
+		utils.PrintInfo(fmt.Sprintf("Installing %s", strings.Join(order, ", ")))
+	}
+
+	for i, name := range order {
+		if len(order) > 1 {
+			fmt.Printf("\n📦 [%d/%d] %s\n", i+1, len(order), name)
+		}
+		if err := InstallService(name); err != nil {
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+	}
+
+	printSuggestions(order)
+	return nil
+}
+
+// printSuggestions mentions suggested companions that are neither installed
+// nor part of the current install
+func printSuggestions(installed []string) {
+	included := make(map[string]bool)
+	for _, name := range installed {
+		included[name] = true
+	}
+
+	for _, name := range installed {
+		for _, suggestion := range serviceRegistry[name].Suggests {
+			if included[suggestion] || IsServiceInstalled(suggestion) {
+				continue
+			}
+			included[suggestion] = true
+			utils.PrintInfo(fmt.Sprintf("%s works well with %s, install it with: bootup install %s", name, suggestion, suggestion))
+		}
+	}
+}
@@ -1,16 +1,15 @@
 package services
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"runtime"
 	"strconv"
 	"strings"
 
+	"github.com/amirkh8006/bootup-cli/internal/platform"
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
@@ -32,27 +31,35 @@ type GoVersion struct {
 // GoReleaseResponse represents the Go releases API response
 type GoReleaseResponse []GoVersion
 
-func InstallGolang() error {
+// goReleasesURL lists the current Go releases with their files
+const goReleasesURL = "https://go.dev/dl/?mode=json"
+
+// GolangPlan asks which Go version to install, unless one was requested,
+// and builds its install plan
+func GolangPlan() (*Plan, error) {
+	if version := requestedVersion("golang"); version != "" {
+		return goVersionPlan("go" + normalizeVersion(version))
+	}
+
 	utils.PrintInfo("Fetching available Go versions...")
 
 	// Fetch available versions
 	versions, err := fetchGoVersions()
 	if err != nil {
-		return fmt.Errorf("failed to fetch Go versions: %w", err)
+		return nil, fmt.Errorf("failed to fetch Go versions: %w", err)
 	}
 
 	// Display versions to user
 	selectedVersion, err := displayAndSelectGoVersion(versions)
 	if err != nil {
-		return fmt.Errorf("failed to select version: %w", err)
+		return nil, fmt.Errorf("failed to select version: %w", err)
 	}
 
-	// Install selected version
-	return installGoVersion(selectedVersion)
+	return goVersionPlan(selectedVersion)
 }
 
 func fetchGoVersions() (*GoReleaseResponse, error) {
-	resp, err := http.Get("https://go.dev/dl/?mode=json")
+	resp, err := http.Get(goReleasesURL)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +78,39 @@ func fetchGoVersions() (*GoReleaseResponse, error) {
 	return &versions, nil
 }
 
+// goDownload fetches a Go release archive, verified against the SHA-256 the
+// go.dev release list publishes for it
+func goDownload(filename, path string) Step {
+	url := fmt.Sprintf("https://go.dev/dl/%s", filename)
+	return download(fmt.Sprintf("Downloading Go from %s", url), url, path, goReleasesURL+"&include=all", func() (string, error) {
+		return goFileChecksum(filename)
+	})
+}
+
+// goFileChecksum looks up the checksum of a release file in the full go.dev
+// release list, which also covers versions no longer offered by default
+func goFileChecksum(filename string) (string, error) {
+	resp, err := http.Get(goReleasesURL + "&include=all")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var versions GoReleaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return "", fmt.Errorf("failed to parse Go releases: %w", err)
+	}
+
+	for _, version := range versions {
+		for _, file := range version.Files {
+			if file.Filename == filename && file.Sha256 != "" {
+				return "sha256:" + file.Sha256, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("go.dev lists no checksum for %s", filename)
+}
+
 func displayAndSelectGoVersion(versions *GoReleaseResponse) (string, error) {
 	// Filter stable versions
 	var stableVersions []GoVersion
@@ -87,6 +127,11 @@ func displayAndSelectGoVersion(versions *GoReleaseResponse) (string, error) {
 		return "", fmt.Errorf("no stable Go versions found")
 	}
 
+	if utils.AssumeYes() {
+		fmt.Printf("✅ Selected: %s (default)\n", stableVersions[0].Version)
+		return stableVersions[0].Version, nil
+	}
+
 	// Display available versions
 	fmt.Println("\n📋 Available Go Versions:")
 	fmt.Println("==================================================")
@@ -101,7 +146,7 @@ func displayAndSelectGoVersion(versions *GoReleaseResponse) (string, error) {
 	fmt.Printf("  %d. Custom version (enter manually)\n\n", len(stableVersions[:min(5, len(stableVersions))])+1)
 
 	// Get user selection
-	reader := bufio.NewReader(os.Stdin)
+	reader := utils.Stdin
 	fmt.Print("Please select a version (enter number): ")
 	input, err := reader.ReadString('\n')
 	if err != nil {
@@ -137,165 +182,130 @@ func displayAndSelectGoVersion(versions *GoReleaseResponse) (string, error) {
 	}
 }
 
-func installGoVersion(version string) error {
-	utils.PrintInfo(fmt.Sprintf("Installing Go %s...", version))
-
+func goVersionPlan(version string) (*Plan, error) {
 	// Detect architecture
-	arch := getGoArch()
-	if arch == "" {
-		return fmt.Errorf("unsupported architecture: %s", runtime.GOARCH)
+	arch, err := goArch()
+	if err != nil {
+		return nil, err
 	}
 
 	// Construct download URL
 	filename := fmt.Sprintf("%s.linux-%s.tar.gz", version, arch)
-	downloadURL := fmt.Sprintf("https://go.dev/dl/%s", filename)
-
-	utils.PrintInfo(fmt.Sprintf("Downloading Go from: %s", downloadURL))
+	downloadPath := fmt.Sprintf("/tmp/%s", filename)
 
-	// Create temporary directory
-	tmpDir := "/tmp/go-install"
-	if err := utils.RunCommand("mkdir", "-p", tmpDir); err != nil {
-		return fmt.Errorf("failed to create temporary directory: %w", err)
-	}
+	homeDir := os.Getenv("HOME")
+	workspaceDir := fmt.Sprintf("%s/go", homeDir)
 
-	defer func() { _ = utils.RunCommand("rm", "-rf", tmpDir) }()
+	// Ask about workspace setup before anything is changed
+	createWorkspace := settingBool("golang", "workspace", "\n🤔 Would you like to create a Go workspace directory?")
+	setupGopath := createWorkspace && settingBool("golang", "gopath", "🤔 Set up traditional GOPATH workspace?")
 
-	// Download Go
-	downloadPath := fmt.Sprintf("%s/%s", tmpDir, filename)
-	downloadCmd := fmt.Sprintf("wget -O '%s' '%s'", downloadPath, downloadURL)
-	if err := utils.RunCommandShell(downloadCmd); err != nil {
-		utils.PrintWarning("wget failed, trying curl...")
-		downloadCmd = fmt.Sprintf("curl -L -o '%s' '%s'", downloadPath, downloadURL)
-		if err := utils.RunCommandShell(downloadCmd); err != nil {
-			return fmt.Errorf("failed to download Go: %w", err)
-		}
+	plan := NewPlan("golang")
+	plan.Version = version
+	if createWorkspace {
+		plan.Config = map[string]string{"workspace": workspaceDir}
+	}
+	plan.Add(
+		goDownload(filename, downloadPath),
+		command("Removing any existing Go installation", "sudo", "rm", "-rf", "/usr/local/go").optional(),
+		extract("Extracting Go", downloadPath, "/usr/local", 0),
+		action("Adding Go to PATH in shell configuration files", func() error {
+			return appendToShellConfigs(homeDir, "\n# Go Programming Language\nexport PATH=$PATH:/usr/local/go/bin\n", "/usr/local/go/bin")
+		}),
+		writeFile("Adding Go to system PATH", "/etc/profile.d/go.sh", "export PATH=$PATH:/usr/local/go/bin\n", 0644).optional(),
+		verify("Verifying installation", "/usr/local/go/bin/go", "version"),
+	)
+
+	if createWorkspace {
+		plan.Add(command(fmt.Sprintf("Creating Go workspace at %s", workspaceDir),
+			"mkdir", "-p", workspaceDir+"/src", workspaceDir+"/bin", workspaceDir+"/pkg").optional())
+	}
+	if setupGopath {
+		gopathExport := fmt.Sprintf("\nexport GOPATH=%s\nexport PATH=$PATH:$GOPATH/bin\n", workspaceDir)
+		plan.Add(action("Configuring GOPATH workspace", func() error {
+			return appendToShellConfigs(homeDir, gopathExport, "export GOPATH=")
+		}).optional())
 	}
 
-	// Remove existing Go installation
-	utils.PrintInfo("Removing any existing Go installation...")
-	_ = utils.RunCommand("sudo", "rm", "-rf", "/usr/local/go")
+	plan.Add(command("Cleaning up downloaded archive", "rm", "-f", downloadPath).optional())
+
+	plan.Success = fmt.Sprintf("Go %s installed successfully! 🎉", version)
+	plan.Note(
+		"Next steps:",
+		"1. Restart your terminal or run: source ~/.bashrc (or ~/.zshrc)",
+		"2. Verify installation: go version",
+		"3. Create your first Go project: mkdir hello-world && cd hello-world && go mod init hello-world",
+	)
+	return plan, nil
+}
 
-	// Extract Go
-	utils.PrintInfo("Extracting Go...")
-	if err := utils.RunCommand("sudo", "tar", "-C", "/usr/local", "-xzf", downloadPath); err != nil {
-		return fmt.Errorf("failed to extract Go: %w", err)
+// GolangUpgradePlan replaces /usr/local/go with another release. The PATH
+// entries and workspace from the original install are kept.
+func GolangUpgradePlan(version string) (*Plan, error) {
+	arch, err := goArch()
+	if err != nil {
+		return nil, err
 	}
 
-	// Set up environment variables
-	utils.PrintInfo("Setting up environment variables...")
+	version = "go" + normalizeVersion(version)
+	filename := fmt.Sprintf("%s.linux-%s.tar.gz", version, arch)
+	downloadPath := fmt.Sprintf("/tmp/%s", filename)
+
+	plan := NewPlan("golang")
+	plan.Version = version
+	plan.Add(
+		goDownload(filename, downloadPath),
+		command("Removing the previous Go installation", "sudo", "rm", "-rf", "/usr/local/go"),
+		extract("Extracting Go", downloadPath, "/usr/local", 0),
+		verify("Verifying installation", "/usr/local/go/bin/go", "version"),
+		command("Cleaning up downloaded archive", "rm", "-f", downloadPath).optional(),
+	)
+	plan.Success = fmt.Sprintf("Go upgraded to %s! 🎉", version)
+	return plan, nil
+}
+
+// GolangUninstallPlan builds the removal plan for Go
+func GolangUninstallPlan(purge bool) (*Plan, error) {
+	return uninstallPlan("golang", uninstallSpec{
+		files: []string{"/usr/local/go", "/etc/profile.d/go.sh"},
+		notes: []string{
+			"The PATH entries added to ~/.bashrc, ~/.zshrc and ~/.profile were left in place",
+			"Your Go workspace (~/go) was not touched",
+		},
+	}, purge), nil
+}
 
-	// Add Go to PATH in various shell configuration files
+// appendToShellConfigs appends content to the user's existing shell rc files
+// unless they already contain marker
+func appendToShellConfigs(homeDir, content, marker string) error {
 	shells := []string{".bashrc", ".zshrc", ".profile"}
-	homeDir := os.Getenv("HOME")
-
-	goPathExport := "\n# Go Programming Language\nexport PATH=$PATH:/usr/local/go/bin\n"
 
 	for _, shell := range shells {
 		shellPath := fmt.Sprintf("%s/%s", homeDir, shell)
-		if _, err := os.Stat(shellPath); err == nil {
-			// Check if Go PATH is already in the file
-			content, err := os.ReadFile(shellPath)
-			if err == nil && !strings.Contains(string(content), "/usr/local/go/bin") {
-				file, err := os.OpenFile(shellPath, os.O_APPEND|os.O_WRONLY, 0644)
-				if err == nil {
-					if _, err := file.WriteString(goPathExport); err != nil {
-						file.Close()
-						continue
-					}
-					file.Close()
-					utils.PrintInfo(fmt.Sprintf("Added Go to PATH in %s", shell))
-				}
-			}
+		existing, err := os.ReadFile(shellPath)
+		if err != nil || strings.Contains(string(existing), marker) {
+			continue
 		}
-	}
-
-	// Add Go to system-wide PATH
-	systemProfile := "/etc/profile.d/go.sh"
-	goSystemPath := "export PATH=$PATH:/usr/local/go/bin\n"
-
-	utils.PrintInfo("Adding Go to system PATH...")
-	writeSystemPathCmd := fmt.Sprintf("echo '%s' | sudo tee %s", goSystemPath, systemProfile)
-	if err := utils.RunCommandShell(writeSystemPathCmd); err != nil {
-		utils.PrintWarning("Failed to add Go to system PATH, but installation succeeded")
-	}
-
-	// Set current session PATH for verification
-	os.Setenv("PATH", os.Getenv("PATH")+":/usr/local/go/bin")
-
-	// Verify installation
-	utils.PrintInfo("Verifying installation...")
-	if err := utils.RunCommand("/usr/local/go/bin/go", "version"); err != nil {
-		utils.PrintError("Go installation verification failed")
-		return err
-	}
-
-	utils.PrintSuccess(fmt.Sprintf("Go %s installed successfully! 🎉", version))
-
-	// Display next steps
-	fmt.Println("\n📝 Next Steps:")
-	fmt.Println("==================================================")
-	fmt.Println("1. Restart your terminal or run: source ~/.bashrc (or ~/.zshrc)")
-	fmt.Println("2. Verify installation: go version")
-	fmt.Println("3. Create your first Go project:")
-	fmt.Println("   mkdir hello-world && cd hello-world")
-	fmt.Println("   go mod init hello-world")
-	fmt.Println("   echo 'package main\n\nimport \"fmt\"\n\nfunc main() {\n    fmt.Println(\"Hello, World!\")\n}' > main.go")
-	fmt.Println("   go run main.go")
-
-	// Ask about workspace setup
-	fmt.Print("\n🤔 Would you like to create a Go workspace directory? (y/n): ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-
-	if strings.ToLower(strings.TrimSpace(input)) == "y" {
-		workspaceDir := fmt.Sprintf("%s/go", os.Getenv("HOME"))
-		utils.PrintInfo(fmt.Sprintf("Creating Go workspace at %s...", workspaceDir))
-
-		if err := utils.RunCommand("mkdir", "-p", workspaceDir+"/src", workspaceDir+"/bin", workspaceDir+"/pkg"); err != nil {
-			utils.PrintError("Failed to create Go workspace, but Go installation was successful")
-		} else {
-			utils.PrintSuccess("Go workspace created successfully!")
-			fmt.Printf("📁 Workspace location: %s\n", workspaceDir)
-
-			// Add GOPATH to shell configs if user wants traditional workspace
-			fmt.Print("🤔 Set up traditional GOPATH workspace? (y/n): ")
-			gopathInput, _ := reader.ReadString('\n')
-			if strings.ToLower(strings.TrimSpace(gopathInput)) == "y" {
-				gopathExport := fmt.Sprintf("\nexport GOPATH=%s\nexport PATH=$PATH:$GOPATH/bin\n", workspaceDir)
-				for _, shell := range shells {
-					shellPath := fmt.Sprintf("%s/%s", os.Getenv("HOME"), shell)
-					if _, err := os.Stat(shellPath); err == nil {
-						file, err := os.OpenFile(shellPath, os.O_APPEND|os.O_WRONLY, 0644)
-						if err == nil {
-							if _, err := file.WriteString(gopathExport); err != nil {
-								file.Close()
-								continue
-							}
-							file.Close()
-						}
-					}
-				}
-				utils.PrintSuccess("GOPATH workspace configured!")
-			}
+		if err := utils.AppendToFile(shellPath, content); err != nil {
+			return fmt.Errorf("failed to update %s: %w", shell, err)
 		}
+		utils.PrintInfo(fmt.Sprintf("Updated %s", shell))
 	}
 
 	return nil
 }
 
-func getGoArch() string {
-	switch runtime.GOARCH {
-	case "amd64":
-		return "amd64"
-	case "arm64":
-		return "arm64"
-	case "386":
-		return "386"
-	case "arm":
-		return "armv6l"
-	default:
-		return ""
+// goArch returns the architecture as go.dev names it in release filenames
+func goArch() (string, error) {
+	arch, err := platform.DetectArch()
+	if err != nil {
+		return "", err
+	}
+	// Go publishes a single 32-bit ARM build, for ARMv6 and later
+	if arch.Name == "arm" {
+		return "armv6l", nil
 	}
+	return arch.Name, nil
 }
 
 func min(a, b int) int {
@@ -2,72 +2,72 @@ package services
 
 import (
 	"fmt"
-
-	"github.com/amirkh8006/bootup-cli/internal/utils"
+	"path/filepath"
+	"strconv"
 )
 
-func InstallGrafana() error {
-	utils.PrintInfo("Installing Grafana...")
-
-	// Install prerequisites
-	utils.PrintInfo("Installing prerequisites...")
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "apt-transport-https", "software-properties-common", "wget"); err != nil {
-		return fmt.Errorf("failed to install prerequisites for Grafana: %w", err)
-	}
-
-	// Create keyrings directory
-	utils.PrintInfo("Creating keyrings directory...")
-	if err := utils.RunCommand("sudo", "mkdir", "-p", "/etc/apt/keyrings/"); err != nil {
-		return fmt.Errorf("failed to create keyrings directory: %w", err)
-	}
-
-	// Add Grafana GPG key
-	utils.PrintInfo("Adding Grafana GPG key...")
-	gpgKeyCmd := "wget -q -O - https://apt.grafana.com/gpg.key | gpg --dearmor | sudo tee /etc/apt/keyrings/grafana.gpg > /dev/null"
-	if err := utils.RunCommandShell(gpgKeyCmd); err != nil {
-		return fmt.Errorf("failed to add Grafana GPG key: %w", err)
-	}
+// grafanaPortDropIn overrides the HTTP port of the packaged Grafana unit
+const grafanaPortDropIn = "/etc/systemd/system/grafana-server.service.d/bootup-port.conf"
 
-	// Add Grafana repository
-	utils.PrintInfo("Adding Grafana repository...")
-	repoCmd := "echo 'deb [signed-by=/etc/apt/keyrings/grafana.gpg] https://apt.grafana.com stable main' | sudo tee -a /etc/apt/sources.list.d/grafana.list"
-	if err := utils.RunCommandShell(repoCmd); err != nil {
-		return fmt.Errorf("failed to add Grafana repository: %w", err)
+// GrafanaPlan builds the install plan for Grafana
+func GrafanaPlan() (*Plan, error) {
+	port, err := servicePort("grafana", "port")
+	if err != nil {
+		return nil, err
 	}
 
-	// Update package lists
-	utils.PrintInfo("Updating package lists...")
-	if err := utils.RunCommand("sudo", "apt-get", "update", "-y"); err != nil {
-		return fmt.Errorf("failed to update package lists after adding Grafana repo: %w", err)
+	plan, err := packagePlan("grafana", "Grafana")
+	if err != nil {
+		return nil, err
 	}
+	plan.Config = map[string]string{"port": strconv.Itoa(port)}
 
-	// Install Grafana
-	utils.PrintInfo("Installing Grafana package...")
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "grafana"); err != nil {
-		return fmt.Errorf("failed to install Grafana: %w", err)
+	if port != 3000 {
+		if err := requireSystemd("changing the Grafana port"); err != nil {
+			return nil, err
+		}
+		plan.Add(
+			directory("Creating Grafana unit override directory", filepath.Dir(grafanaPortDropIn), ""),
+			writeFile("Setting Grafana HTTP port", grafanaPortDropIn,
+				fmt.Sprintf("[Service]\nEnvironment=GF_SERVER_HTTP_PORT=%d\n", port), 0644),
+			command("Reloading systemd daemon", "sudo", "systemctl", "daemon-reload"),
+			restartService("grafana-server"),
+		)
 	}
 
-	// Reload systemd daemon
-	utils.PrintInfo("Reloading systemd daemon...")
-	if err := utils.RunCommand("sudo", "systemctl", "daemon-reload"); err != nil {
-		return fmt.Errorf("failed to reload systemd daemon: %w", err)
+	// The admin user only exists once Grafana has created its database
+	plan.Add(waitHealthy("Waiting for Grafana to start", func() error {
+		return httpProbe("/api/health")(port)
+	}))
+	plan.Add(credentialSteps("grafana", serviceCredentials("grafana"))...)
+	scrape, err := scrapeTargetSteps("grafana")
+	if err != nil {
+		return nil, err
 	}
-
-	// Enable Grafana service
-	utils.PrintInfo("Enabling Grafana service...")
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "grafana-server"); err != nil {
-		return fmt.Errorf("failed to enable Grafana service: %w", err)
+	plan.Add(scrape...)
+	if IsServiceInstalled("prometheus") {
+		plan.Add(grafanaProvisioningSteps(installedPrometheusSettings())...)
 	}
 
-	// Start Grafana service
-	utils.PrintInfo("Starting Grafana service...")
-	if err := utils.RunCommand("sudo", "systemctl", "start", "grafana-server"); err != nil {
-		return fmt.Errorf("failed to start Grafana service: %w", err)
+	plan.Success = "Grafana installed and running!"
+	plan.Note(
+		fmt.Sprintf("Grafana is accessible at http://localhost:%d", port),
+		"Admin user: admin",
+		credentialsNote("grafana"),
+	)
+	if IsServiceInstalled("prometheus") {
+		plan.Note(grafanaDashboardNote(port))
 	}
+	return plan, nil
+}
 
-	utils.PrintSuccess("Grafana installed and running!")
-	utils.PrintInfo("Grafana is accessible at http://localhost:3000")
-	utils.PrintInfo("Default login: admin/admin")
-
-	return nil
+// GrafanaUninstallPlan builds the removal plan for Grafana
+func GrafanaUninstallPlan(purge bool) (*Plan, error) {
+	return packageUninstallPlan("grafana", uninstallSpec{
+		files: []string{
+			grafanaPortDropIn, prometheusTargetFile("grafana"),
+			grafanaDatasourceFile, grafanaDashboardProvider, grafanaDashboardsDir,
+		},
+		dataDirs: []string{"/var/lib/grafana", "/var/log/grafana", "/etc/grafana"},
+	}, purge)
 }
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+)
+
+// JavaPlan builds the install plan for the OpenJDK 17 runtime
+func JavaPlan() (*Plan, error) {
+	plan, err := packagePlan("java", "OpenJDK 17")
+	if err != nil {
+		return nil, err
+	}
+	plan.Version = "17"
+	plan.Add(verify("Verifying installation", "java", "-version"))
+	plan.Success = "Java 17 installed successfully!"
+	return plan, nil
+}
+
+// JavaUninstallPlan builds the removal plan for Java
+func JavaUninstallPlan(purge bool) (*Plan, error) {
+	return packageUninstallPlan("java", uninstallSpec{}, purge)
+}
+
+// javaHome returns the home of the java on the PATH, which may be any
+// release Kafka accepts, or where the OpenJDK 17 package installs the JDK on
+// the running distribution when there is none yet
+func javaHome() (string, error) {
+	if path, err := exec.LookPath("java"); err == nil {
+		// Resolve /usr/bin/java and the alternatives links to <home>/bin/java
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Dir(filepath.Dir(resolved)), nil
+		}
+	}
+
+	host, err := platform.Detect()
+	if err != nil {
+		return "", err
+	}
+	switch host.Family {
+	case platform.Debian:
+		arch, err := platform.DetectArch()
+		if err != nil {
+			return "", err
+		}
+		return "/usr/lib/jvm/java-17-openjdk-" + arch.Debian, nil
+	case platform.RHEL:
+		return "/usr/lib/jvm/jre-17-openjdk", nil
+	}
+	return "/usr/lib/jvm/java-17-openjdk", nil
+}
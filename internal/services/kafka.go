@@ -3,125 +3,86 @@ package services
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
 const (
-	kafkaVersion    = "4.1.0"
 	kafkaInstallDir = "/opt/kafka"
 	kafkaDataDir    = "/var/lib/kafka/data"
-	scalaVersion    = "2.13"
+	kafkaTarball    = "/tmp/kafka.tgz"
 )
 
-func InstallKafka() error {
-	utils.PrintInfo("Installing Kafka 4.1.0 (KRaft mode)...")
-
-	// Install dependencies
-	utils.PrintInfo("Installing Java 17 and dependencies...")
-	if err := utils.RunCommand("sudo", "apt-get", "update", "-y"); err != nil {
-		return fmt.Errorf("failed to update packages: %w", err)
-	}
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "openjdk-17-jdk", "wget", "tar", "uuid-runtime"); err != nil {
-		return fmt.Errorf("failed to install dependencies: %w", err)
-	}
-
-	// Download Kafka
-	utils.PrintInfo("Downloading Kafka...")
-	kafkaUrl := fmt.Sprintf("https://downloads.apache.org/kafka/%s/kafka_%s-%s.tgz", kafkaVersion, scalaVersion, kafkaVersion)
-	if err := utils.RunCommand("wget", kafkaUrl, "-O", "/tmp/kafka.tgz"); err != nil {
-		return fmt.Errorf("failed to download Kafka: %w", err)
-	}
-
-	// Create installation directory and extract Kafka
-	utils.PrintInfo("Extracting Kafka...")
-	if err := utils.RunCommand("sudo", "mkdir", "-p", kafkaInstallDir); err != nil {
-		return fmt.Errorf("failed to create installation directory: %w", err)
-	}
-	if err := utils.RunCommand("sudo", "tar", "-xzf", "/tmp/kafka.tgz", "--strip-components=1", "-C", kafkaInstallDir); err != nil {
-		return fmt.Errorf("failed to extract Kafka: %w", err)
-	}
-
-	// Create data and config directories
-	utils.PrintInfo("Setting up directories and permissions...")
-	if err := utils.RunCommand("sudo", "mkdir", "-p", kafkaDataDir); err != nil {
-		return fmt.Errorf("failed to create data directory: %w", err)
-	}
-	if err := utils.RunCommand("sudo", "mkdir", "-p", kafkaInstallDir+"/config/kraft"); err != nil {
-		return fmt.Errorf("failed to create kraft config directory: %w", err)
-	}
-
+// KafkaPlan builds the install plan for Kafka in KRaft mode
+func KafkaPlan() (*Plan, error) {
 	// Get current user for ownership
 	currentUser := os.Getenv("USER")
 	if currentUser == "" {
 		currentUser = "ubuntu" // fallback for some environments
 	}
+	owner := currentUser + ":" + currentUser
 
-	if err := utils.RunCommand("sudo", "chown", currentUser+":"+currentUser, kafkaDataDir); err != nil {
-		return fmt.Errorf("failed to set data directory ownership: %w", err)
-	}
-	if err := utils.RunCommand("sudo", "chmod", "-R", "700", kafkaDataDir); err != nil {
-		return fmt.Errorf("failed to set data directory permissions: %w", err)
-	}
-	if err := utils.RunCommand("sudo", "chown", "-R", currentUser+":"+currentUser, kafkaInstallDir); err != nil {
-		return fmt.Errorf("failed to set kafka directory ownership: %w", err)
-	}
-
-	// Create KRaft configuration file
-	utils.PrintInfo("Creating KRaft configuration...")
-	if err := createKraftConfig(); err != nil {
-		return fmt.Errorf("failed to create KRaft config: %w", err)
-	}
-
-	// Format Kafka storage
-	utils.PrintInfo("Formatting Kafka storage for KRaft mode...")
-	if err := utils.RunCommandShell("sudo rm -rf /var/lib/kafka/data/*"); err != nil {
-		return fmt.Errorf("failed to clean data directory: %w", err)
-	}
-
-	// Generate cluster UUID and format storage
-	uuidCmd := fmt.Sprintf("%s/bin/kafka-storage.sh format -t $(uuidgen) -c %s/config/kraft/server.properties", kafkaInstallDir, kafkaInstallDir)
-	if err := utils.RunCommandShell(uuidCmd); err != nil {
-		return fmt.Errorf("failed to format Kafka storage: %w", err)
-	}
-
-	// Create systemd service
-	utils.PrintInfo("Creating systemd service...")
-	if err := createKafkaService(currentUser); err != nil {
-		return fmt.Errorf("failed to create systemd service: %w", err)
-	}
-
-	// Enable and start service
-	utils.PrintInfo("Enabling and starting Kafka service...")
-	if err := utils.RunCommand("sudo", "systemctl", "daemon-reload"); err != nil {
-		return fmt.Errorf("failed to reload systemd: %w", err)
+	release := selectRelease("kafka")
+	javaHome, err := javaHome()
+	if err != nil {
+		return nil, err
 	}
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "kafka"); err != nil {
-		return fmt.Errorf("failed to enable Kafka service: %w", err)
+	port, err := servicePort("kafka", "port")
+	if err != nil {
+		return nil, err
 	}
-	if err := utils.RunCommand("sudo", "systemctl", "start", "kafka"); err != nil {
-		return fmt.Errorf("failed to start Kafka service: %w", err)
+	controllerPort, err := servicePort("kafka", "controller_port")
+	if err != nil {
+		return nil, err
 	}
+	configPath := kafkaInstallDir + "/config/kraft/server.properties"
 
-	// Clean up downloaded file
-	_ = utils.RunCommand("rm", "-f", "/tmp/kafka.tgz")
-
-	utils.PrintSuccess("Kafka installation complete!")
-	utils.PrintInfo("Kafka is running on localhost:9092")
-	utils.PrintInfo("You can check status with: sudo systemctl status kafka")
-
-	return nil
+	plan := NewPlan("kafka")
+	plan.Version = release.Version
+	plan.Config = map[string]string{
+		"config_file":     configPath,
+		"data_dir":        kafkaDataDir,
+		"user":            currentUser,
+		"port":            strconv.Itoa(port),
+		"controller_port": strconv.Itoa(controllerPort),
+	}
+	plan.Add(
+		packageUpdate(),
+		packageInstall("Installing dependencies", "tar"),
+		release.download(fmt.Sprintf("Downloading Kafka %s", release.Version), kafkaTarball),
+		extract("Extracting Kafka", kafkaTarball, kafkaInstallDir, 1),
+		directory("Creating data directory", kafkaDataDir, owner),
+		command("Setting data directory permissions", "sudo", "chmod", "-R", "700", kafkaDataDir),
+		directory("Creating kraft config directory", kafkaInstallDir+"/config/kraft", ""),
+		command("Setting kafka directory ownership", "sudo", "chown", "-R", owner, kafkaInstallDir),
+		writeFile("Creating KRaft configuration", configPath, kraftConfig(release.Version, port, controllerPort), 0644),
+		shellCommand("Cleaning data directory", "sudo rm -rf "+kafkaDataDir+"/*"),
+		shellCommand("Formatting Kafka storage for KRaft mode",
+			fmt.Sprintf("%s/bin/kafka-storage.sh format -t $(%s/bin/kafka-storage.sh random-uuid) -c %s", kafkaInstallDir, kafkaInstallDir, configPath)),
+		systemdUnit("kafka", kafkaServiceUnit(currentUser, javaHome)),
+		enableStart("kafka"),
+		command("Cleaning up downloaded archive", "rm", "-f", kafkaTarball).optional(),
+	)
+	plan.Success = "Kafka installation complete!"
+	plan.Note(
+		fmt.Sprintf("Kafka is running on localhost:%d", port),
+		"You can check status with: sudo systemctl status kafka",
+	)
+	return plan, nil
 }
 
-func createKraftConfig() error {
-	configContent := `# Kafka 4.1.0 KRaft single-node
+func kraftConfig(version string, port, controllerPort int) string {
+	return fmt.Sprintf(`# Kafka %s KRaft single-node
 process.roles=broker,controller
 node.id=1
-controller.quorum.voters=1@localhost:9093
+controller.quorum.voters=1@localhost:%[3]d
 
 # Listeners
-listeners=PLAINTEXT://:9092,CONTROLLER://:9093
-advertised.listeners=PLAINTEXT://localhost:9092
+listeners=PLAINTEXT://:%[2]d,CONTROLLER://:%[3]d
+advertised.listeners=PLAINTEXT://localhost:%[2]d
 listener.security.protocol.map=PLAINTEXT:PLAINTEXT,CONTROLLER:PLAINTEXT
 controller.listener.names=CONTROLLER
 
@@ -134,34 +95,18 @@ transaction.state.log.min.isr=1
 log.retention.hours=168
 group.initial.rebalance.delay.ms=0
 
-`
-
-	configPath := kafkaInstallDir + "/config/kraft/server.properties"
-
-	// Create a temporary file with the config content
-	tempFile := "/tmp/kafka_config.tmp"
-	if err := os.WriteFile(tempFile, []byte(configContent), 0644); err != nil {
-		return fmt.Errorf("failed to create temporary config file: %w", err)
-	}
-	defer os.Remove(tempFile)
-
-	// Copy the config using sudo
-	if err := utils.RunCommandShell(fmt.Sprintf("sudo cp %s %s", tempFile, configPath)); err != nil {
-		return fmt.Errorf("failed to copy config file: %w", err)
-	}
-
-	return nil
+`, version, port, controllerPort)
 }
 
-func createKafkaService(user string) error {
-	serviceContent := fmt.Sprintf(`[Unit]
+func kafkaServiceUnit(user, javaHome string) string {
+	return fmt.Sprintf(`[Unit]
 Description=Apache Kafka (KRaft mode)
 After=network.target
 
 [Service]
 Type=simple
 User=%s
-Environment="JAVA_HOME=/usr/lib/jvm/java-17-openjdk-amd64"
+Environment="JAVA_HOME=%s"
 ExecStart=/opt/kafka/bin/kafka-server-start.sh /opt/kafka/config/kraft/server.properties
 ExecStop=/opt/kafka/bin/kafka-server-stop.sh
 Restart=on-failure
@@ -170,21 +115,70 @@ WorkingDirectory=/opt/kafka
 
 [Install]
 WantedBy=multi-user.target
-`, user)
+`, user, javaHome)
+}
 
-	servicePath := "/etc/systemd/system/kafka.service"
+// KafkaUninstallPlan builds the removal plan for Kafka
+func KafkaUninstallPlan(purge bool) (*Plan, error) {
+	return uninstallPlan("kafka", uninstallSpec{
+		units:     []string{"kafka"},
+		unitFiles: []string{"kafka"},
+		files:     []string{kafkaInstallDir},
+		dataDirs:  []string{"/var/lib/kafka"},
+		notes:     []string{"Java (openjdk-17-jdk) was kept since other software may depend on it"},
+	}, purge), nil
+}
 
-	// Create a temporary file with the service content
-	tempFile := "/tmp/kafka_service.tmp"
-	if err := os.WriteFile(tempFile, []byte(serviceContent), 0644); err != nil {
-		return fmt.Errorf("failed to create temporary service file: %w", err)
-	}
-	defer os.Remove(tempFile)
+// KafkaUpgradePlan replaces the Kafka scripts and libraries with another
+// release. The configuration and the data directory are kept, so the
+// storage is not formatted again.
+func KafkaUpgradePlan(version string) (*Plan, error) {
+	release := releaseVersion("kafka", version)
+	workDir := filepath.Join(os.TempDir(), "bootup-kafka-upgrade")
+
+	// Keep the ownership the install gave the kafka directory
+	owner := ""
+	if record, ok := GetInstallRecord("kafka"); ok && record.Config["user"] != "" {
+		owner = record.Config["user"] + ":" + record.Config["user"]
+	}
+
+	plan := NewPlan("kafka")
+	plan.Version = release.Version
+	plan.Add(
+		release.download(fmt.Sprintf("Downloading Kafka %s", release.Version), kafkaTarball),
+		extract("Extracting Kafka", kafkaTarball, workDir, 1),
+		stopService("kafka"),
+		command("Removing old Kafka scripts and libraries", "sudo", "rm", "-rf", kafkaInstallDir+"/bin", kafkaInstallDir+"/libs"),
+		command("Installing new Kafka scripts and libraries", "sudo", "cp", "-a", workDir+"/bin", workDir+"/libs", kafkaInstallDir+"/"),
+	)
+	if owner != "" {
+		plan.Add(command("Setting kafka directory ownership", "sudo", "chown", "-R", owner, kafkaInstallDir))
+	}
+	plan.Add(
+		startService("kafka"),
+		command("Cleaning up temporary files", "sudo", "rm", "-rf", workDir, kafkaTarball).optional(),
+	)
+	plan.Success = fmt.Sprintf("Kafka upgraded to %s!", release.Version)
+	return plan, nil
+}
 
-	// Copy the service file using sudo
-	if err := utils.RunCommandShell(fmt.Sprintf("sudo cp %s %s", tempFile, servicePath)); err != nil {
-		return fmt.Errorf("failed to copy service file: %w", err)
-	}
+// javaVersion finds the major version in `java -version` output such as
+// openjdk version "17.0.12" 2024-07-16, or "1.8.0_422" for Java 8
+var javaVersion = regexp.MustCompile(`version "(?:1\.)?(\d+)`)
 
-	return nil
+// kafkaPreflight checks that an installed Java is new enough for Kafka,
+// which needs Java 17 since 4.0
+func kafkaPreflight() []Check {
+	if !isCommandAvailable("java") {
+		return nil
+	}
+	output, err := utils.CommandCombinedOutput("java", "-version")
+	match := javaVersion.FindStringSubmatch(output)
+	if err != nil || match == nil {
+		return []Check{{"java", CheckWarn, "failed to determine the installed Java version"}}
+	}
+	if major, _ := strconv.Atoi(match[1]); major < 17 {
+		return []Check{{"java", CheckFail, fmt.Sprintf("Kafka needs Java 17 or newer, Java %d is installed", major)}}
+	}
+	return []Check{{"java", CheckPass, fmt.Sprintf("Java %s is installed", match[1])}}
 }
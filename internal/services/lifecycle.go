@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// lifecycleVerbs maps the init system verbs bootup wraps to the step
+// description and the past tense used in the success message
+var lifecycleVerbs = map[string][2]string{
+	"start":   {"Starting", "started"},
+	"stop":    {"Stopping", "stopped"},
+	"restart": {"Restarting", "restarted"},
+	"enable":  {"Enabling", "enabled"},
+	"disable": {"Disabling", "disabled"},
+}
+
+// ServiceUnits returns the systemd units or OpenRC services behind an
+// installed service, e.g. mongod for mongodb or redis-server for redis on
+// Debian
+func ServiceUnits(serviceName string) ([]string, error) {
+	if _, exists := serviceRegistry[serviceName]; !exists {
+		return nil, fmt.Errorf("service %s is not supported", serviceName)
+	}
+	if !IsServiceInstalled(serviceName) {
+		return nil, fmt.Errorf("%s is not installed", serviceName)
+	}
+	units := serviceUnits(serviceName)
+	if len(units) == 0 {
+		return nil, fmt.Errorf("%s does not run as a service", serviceName)
+	}
+	return units, nil
+}
+
+// ControlService starts, stops, restarts, enables or disables the units of
+// an installed service
+func ControlService(serviceName, verb string) error {
+	words, ok := lifecycleVerbs[verb]
+	if !ok {
+		return fmt.Errorf("unknown service action %s", verb)
+	}
+	units, err := ServiceUnits(serviceName)
+	if err != nil {
+		return err
+	}
+
+	plan := NewPlan(serviceName)
+	for _, unit := range units {
+		plan.Add(serviceCommand(fmt.Sprintf("%s %s", words[0], unit), verb, unit))
+	}
+	plan.Success = fmt.Sprintf("%s %s", serviceName, words[1])
+	return plan.Execute()
+}
+
+// ServiceLogs prints the journal of an installed service, following new
+// entries when follow is set
+func ServiceLogs(serviceName string, lines int, follow bool) error {
+	units, err := ServiceUnits(serviceName)
+	if err != nil {
+		return err
+	}
+	host, err := platform.Detect()
+	if err != nil {
+		return err
+	}
+	if host.OpenRC() {
+		return fmt.Errorf("logs need the systemd journal, OpenRC services log to /var/log/%s", strings.TrimSuffix(units[0], ".service"))
+	}
+
+	var argv []string
+	// The journal of system services is only readable by root and the
+	// systemd-journal group
+	if os.Geteuid() != 0 {
+		argv = append(argv, "sudo")
+	}
+	argv = append(argv, "journalctl", "--no-pager", "-n", strconv.Itoa(lines))
+	for _, unit := range units {
+		argv = append(argv, "-u", unit)
+	}
+	if follow {
+		argv = append(argv, "-f")
+	}
+	return utils.RunCommand(argv[0], argv[1:]...)
+}
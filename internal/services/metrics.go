@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+)
+
+// prometheusTargetsDir holds one file_sd target file per service that
+// exposes metrics. Prometheus watches the directory, so a file dropped in
+// or removed is picked up without editing prometheus.yml.
+const prometheusTargetsDir = "/etc/prometheus/targets.d"
+
+// metricsPorts lists the services Prometheus scrapes once installed and
+// the port, by name in portRegistry, they serve /metrics on
+var metricsPorts = map[string]string{
+	"mongodb_exporter":  "port",
+	"nginx_exporter":    "port",
+	"node_exporter":     "port",
+	"postgres_exporter": "port",
+	"redis_exporter":    "port",
+	"grafana":           "port",
+	"rabbitmq":          "prometheus_port",
+	"clickhouse":        "metrics_port",
+	"seaweedfs":         "metrics_port",
+}
+
+// prometheusTargetFile is the file_sd target file of a service
+func prometheusTargetFile(serviceName string) string {
+	return filepath.Join(prometheusTargetsDir, serviceName+".yml")
+}
+
+// prometheusTarget renders the file_sd target file of a service, with the
+// job label naming the service
+func prometheusTarget(serviceName string, port int) string {
+	return fmt.Sprintf(`# Written by bootup, removed when %s is uninstalled
+- targets: ['%s']
+  labels:
+    job: '%s'
+`, serviceName, net.JoinHostPort("localhost", strconv.Itoa(port)), serviceName)
+}
+
+// scrapeTargetSteps register a service with Prometheus by writing its
+// target file. The file is written even when Prometheus is not installed
+// yet, so installing it later picks the service up. An installed
+// Prometheus is reloaded so it reads the file right away rather than on
+// its next file_sd refresh.
+func scrapeTargetSteps(serviceName string) ([]Step, error) {
+	port, err := servicePort(serviceName, metricsPorts[serviceName])
+	if err != nil {
+		return nil, err
+	}
+	steps := []Step{
+		directory("Creating Prometheus targets directory", prometheusTargetsDir, ""),
+		writeFile("Registering metrics with Prometheus", prometheusTargetFile(serviceName), prometheusTarget(serviceName, port), 0644),
+	}
+	if IsServiceInstalled("prometheus") {
+		steps = append(steps, serviceCommand("Reloading Prometheus", "reload", "prometheus").optional())
+	}
+	return steps, nil
+}
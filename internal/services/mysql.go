@@ -1,37 +1,22 @@
 package services
 
-import (
-	"fmt"
-
-	"github.com/amirkh8006/bootup-cli/internal/utils"
-)
-
-func InstallMySQL() error {
-	utils.PrintInfo("Installing MySQL Server locally...")
-
-	// Install MySQL server
-	if err := utils.RunCommand("sudo", "apt-get", "update"); err != nil {
-		return fmt.Errorf("failed to update package list: %w", err)
+// MySQLPlan builds the install plan for MySQL
+func MySQLPlan() (*Plan, error) {
+	plan, err := packagePlan("mysql", "MySQL")
+	if err != nil {
+		return nil, err
 	}
+	plan.Success = "MySQL installed and started successfully!"
+	plan.Note(
+		"Note: Run 'sudo mysql_secure_installation' manually to complete the secure setup",
+		"Default connection: mysql -u root -p",
+	)
+	return plan, nil
+}
 
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "mysql-server"); err != nil {
-		return fmt.Errorf("failed to install MySQL server: %w", err)
-	}
-
-	utils.PrintInfo("Enabling MySQL service...")
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "mysql"); err != nil {
-		return fmt.Errorf("failed to enable MySQL service: %w", err)
-	}
-
-	utils.PrintInfo("Starting MySQL service...")
-	if err := utils.RunCommand("sudo", "systemctl", "start", "mysql"); err != nil {
-		return fmt.Errorf("failed to start MySQL service: %w", err)
-	}
-
-	utils.PrintInfo("Securing MySQL installation...")
-	utils.PrintInfo("Note: Run 'sudo mysql_secure_installation' manually to complete the secure setup")
-
-	utils.PrintSuccess("MySQL installed and started successfully!")
-	utils.PrintInfo("Default connection: mysql -u root -p")
-	return nil
+// MySQLUninstallPlan builds the removal plan for MySQL
+func MySQLUninstallPlan(purge bool) (*Plan, error) {
+	return packageUninstallPlan("mysql", uninstallSpec{
+		dataDirs: []string{"/var/lib/mysql", "/etc/mysql"},
+	}, purge)
 }
@@ -1,21 +1,18 @@
 package services
 
-import (
-	"fmt"
-	"github.com/amirkh8006/bootup-cli/internal/utils"
-)
-
-func InstallNginx() error {
-	utils.PrintInfo("Updating package list...")
-	if err := utils.RunCommand("sudo", "apt-get", "update", "-y"); err != nil {
-		return fmt.Errorf("failed to update packages: %w", err)
-	}
-
-	utils.PrintInfo("Installing Nginx...")
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "nginx", "apache2-utils"); err != nil {
-		return fmt.Errorf("failed to install Nginx: %w", err)
+// NginxPlan builds the install plan for Nginx
+func NginxPlan() (*Plan, error) {
+	plan, err := packagePlan("nginx", "Nginx")
+	if err != nil {
+		return nil, err
 	}
+	plan.Success = "Nginx installed successfully!"
+	return plan, nil
+}
 
-	utils.PrintSuccess("Nginx installed successfully!")
-	return nil
+// NginxUninstallPlan builds the removal plan for Nginx
+func NginxUninstallPlan(purge bool) (*Plan, error) {
+	return packageUninstallPlan("nginx", uninstallSpec{
+		dataDirs: []string{"/etc/nginx", "/var/log/nginx"},
+	}, purge)
 }
@@ -1,18 +1,24 @@
 package services
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
+	"github.com/amirkh8006/bootup-cli/internal/platform"
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
+const (
+	nodesourceKeyring     = "/etc/apt/keyrings/nodesource.gpg"
+	nodesourceRepoList    = "/etc/apt/sources.list.d/nodesource.list"
+	nodesourcePreferences = "/etc/apt/preferences.d/nodejs"
+	nodesourceRepoFile    = "/etc/yum.repos.d/nodesource-nodejs.repo"
+)
+
 // NodeVersion represents a Node.js version
 type NodeVersion struct {
 	Version string      `json:"version"`
@@ -30,23 +36,35 @@ type NodeVersion struct {
 // NodeDistIndex represents the Node.js distribution index
 type NodeDistIndex []NodeVersion
 
-func InstallNodeJS() error {
+// NodeJSPlan asks which Node.js version to install, unless one was requested,
+// and builds its install plan
+func NodeJSPlan() (*Plan, error) {
+	if version := requestedVersion("nodejs"); version != "" {
+		// NodeSource installs the newest release of a major line, so a more
+		// specific version could not be honoured
+		major := normalizeVersion(version)
+		if _, err := strconv.Atoi(major); err != nil {
+			major = extractMajorVersion(major)
+			return nil, fmt.Errorf("nodejs %s cannot be pinned, request the major version %s and NodeSource installs the newest %s.x", version, major, major)
+		}
+		return nodeVersionPlan("v" + major)
+	}
+
 	utils.PrintInfo("Fetching available Node.js versions...")
 
 	// Fetch available versions
 	versions, err := fetchNodeVersions()
 	if err != nil {
-		return fmt.Errorf("failed to fetch Node.js versions: %w", err)
+		return nil, fmt.Errorf("failed to fetch Node.js versions: %w", err)
 	}
 
 	// Display versions to user
 	selectedVersion, err := displayAndSelectVersion(versions)
 	if err != nil {
-		return fmt.Errorf("failed to select version: %w", err)
+		return nil, fmt.Errorf("failed to select version: %w", err)
 	}
 
-	// Install selected version
-	return installNodeVersion(selectedVersion)
+	return nodeVersionPlan(selectedVersion)
 }
 
 func fetchNodeVersions() (*NodeDistIndex, error) {
@@ -90,6 +108,10 @@ func displayAndSelectVersion(versions *NodeDistIndex) (string, error) {
 		}
 	}
 
+	if utils.AssumeYes() {
+		return defaultNodeVersion(currentVersion, ltsVersions)
+	}
+
 	// Display available versions
 	fmt.Println("\n📋 Available Node.js Versions:")
 	fmt.Println("==================================================")
@@ -111,7 +133,7 @@ func displayAndSelectVersion(versions *NodeDistIndex) (string, error) {
 	fmt.Printf("  %d. Custom version (enter manually)\n\n", len(ltsVersions)+2)
 
 	// Get user selection
-	reader := bufio.NewReader(os.Stdin)
+	reader := utils.Stdin
 	fmt.Print("Please select a version (enter number): ")
 	input, err := reader.ReadString('\n')
 	if err != nil {
@@ -148,60 +170,108 @@ func displayAndSelectVersion(versions *NodeDistIndex) (string, error) {
 	}
 }
 
-func installNodeVersion(version string) error {
+// defaultNodeVersion picks the newest LTS release, or the current release
+// when no LTS is listed
+func defaultNodeVersion(currentVersion string, ltsVersions []NodeVersion) (string, error) {
+	selectedVersion := currentVersion
+	if len(ltsVersions) > 0 {
+		selectedVersion = ltsVersions[0].Version
+	}
+	if selectedVersion == "" {
+		return "", fmt.Errorf("no Node.js versions found")
+	}
+	fmt.Printf("✅ Selected: %s (default)\n", selectedVersion)
+	return selectedVersion, nil
+}
+
+func nodeVersionPlan(version string) (*Plan, error) {
 	// Extract major version number for repository setup
 	majorVersion := extractMajorVersion(version)
 
-	utils.PrintInfo(fmt.Sprintf("Installing Node.js %s...", version))
-
-	// Add NodeSource repository
-	utils.PrintInfo("Adding NodeSource repository...")
-	setupCmd := fmt.Sprintf("curl -fsSL https://deb.nodesource.com/setup_%s.x | sudo -E bash -", majorVersion)
-	if err := utils.RunCommandShell(setupCmd); err != nil {
-		return fmt.Errorf("failed to add NodeSource repository: %w", err)
-	}
+	// Optional: Install PM2
+	installPM2 := settingBool("nodejs", "pm2", "\n🤔 Would you like to install PM2 (Process Manager)?")
 
-	// Update package list
-	utils.PrintInfo("Updating package list...")
-	if err := utils.RunCommand("sudo", "apt-get", "update", "-y"); err != nil {
-		return fmt.Errorf("failed to update package list: %w", err)
+	repo, err := nodesourceRepo(majorVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	// Install Node.js
-	utils.PrintInfo("Installing Node.js and npm...")
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "nodejs"); err != nil {
-		return fmt.Errorf("failed to install Node.js: %w", err)
+	plan := NewPlan("nodejs")
+	plan.Version = version
+	if installPM2 {
+		plan.Config = map[string]string{"pm2": "true"}
 	}
-
-	// Verify installation
-	utils.PrintInfo("Verifying installation...")
-	if err := utils.RunCommand("node", "--version"); err != nil {
-		utils.PrintError("Node.js installation verification failed")
-		return err
+	plan.Add(repo...)
+	plan.Add(
+		packageUpdate(),
+		packageInstall("Installing Node.js and npm", "nodejs"),
+		// NodeSource installs the newest release of the major line, which
+		// may differ from the one picked, so record what is installed
+		action("Verifying Node.js installation", func() error {
+			if utils.IsDryRun() {
+				return nil
+			}
+			output, err := utils.CommandOutput("node", "--version")
+			if err != nil {
+				return fmt.Errorf("failed to run node --version: %w", err)
+			}
+			if installed := extractMajorVersion(output); installed != majorVersion {
+				return fmt.Errorf("node %s is installed instead of Node.js %s.x", output, majorVersion)
+			}
+			plan.Version = output
+			plan.Success = fmt.Sprintf("Node.js %s and npm installed successfully! 🎉", plan.Version)
+			return nil
+		}),
+		verify("Verifying npm installation", "npm", "--version"),
+	)
+	if installPM2 {
+		plan.Add(command("Installing PM2", "sudo", "npm", "install", "-g", "pm2").optional())
 	}
+	plan.Success = fmt.Sprintf("Node.js %s and npm installed successfully! 🎉", version)
+	return plan, nil
+}
 
-	if err := utils.RunCommand("npm", "--version"); err != nil {
-		utils.PrintError("npm installation verification failed")
-		return err
+// nodesourceRepo adds the NodeSource repository of a Node.js major version.
+// NodeSource builds packages for the Debian and RHEL families only.
+func nodesourceRepo(majorVersion string) ([]Step, error) {
+	host, err := platform.Detect()
+	if err != nil {
+		return nil, err
 	}
 
-	utils.PrintSuccess(fmt.Sprintf("Node.js %s and npm installed successfully! 🎉", version))
-
-	// Optional: Install PM2
-	fmt.Print("\n🤔 Would you like to install PM2 (Process Manager)? (y/n): ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-
-	if strings.ToLower(strings.TrimSpace(input)) == "y" {
-		utils.PrintInfo("Installing PM2...")
-		if err := utils.RunCommand("sudo", "npm", "install", "-g", "pm2"); err != nil {
-			utils.PrintError("Failed to install PM2, but Node.js installation was successful")
-		} else {
-			utils.PrintSuccess("PM2 installed successfully!")
-		}
+	switch host.Family {
+	case platform.Debian:
+		return []Step{
+			packageInstall("Installing prerequisites", "ca-certificates", "curl", "gnupg"),
+			aptKey("Adding NodeSource GPG key", "https://deb.nodesource.com/gpgkey/nodesource-repo.gpg.key", nodesourceKeyring),
+			repoFile("Adding NodeSource repository", nodesourceRepoList,
+				fmt.Sprintf("deb [signed-by=%s] https://deb.nodesource.com/node_%s.x nodistro main\n", nodesourceKeyring, majorVersion)),
+			writeFile("Preferring NodeSource packages", nodesourcePreferences,
+				"Package: nodejs\nPin: origin deb.nodesource.com\nPin-Priority: 600\n", 0644),
+		}, nil
+	case platform.RHEL:
+		return []Step{
+			repoFile("Adding NodeSource repository", nodesourceRepoFile, fmt.Sprintf(`[nodesource-nodejs]
+name=Node.js Packages for $basearch
+baseurl=https://rpm.nodesource.com/pub_%s.x/nodistro/nodejs/$basearch
+priority=9
+enabled=1
+gpgcheck=1
+gpgkey=https://rpm.nodesource.com/gpgkey/ns-operations-public.key
+module_hotfixes=1
+`, majorVersion)),
+		}, nil
 	}
+	return nil, fmt.Errorf("nodejs is not available on %s", host)
+}
 
-	return nil
+// NodeJSUninstallPlan builds the removal plan for Node.js and the NodeSource repository
+func NodeJSUninstallPlan(purge bool) (*Plan, error) {
+	return uninstallPlan("nodejs", uninstallSpec{
+		packages: []string{"nodejs"},
+		files:    []string{nodesourceRepoList, nodesourcePreferences, nodesourceKeyring, "/usr/share/keyrings/nodesource.gpg", nodesourceRepoFile},
+		dataDirs: []string{"/usr/lib/node_modules"},
+	}, purge), nil
 }
 
 func extractMajorVersion(version string) string {
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// InstallOptions are the choices made up front for a service, from the
+// command line or a stack file, instead of being asked interactively
+type InstallOptions struct {
+	Version  string
+	Settings map[string]string
+}
+
+// installOptions holds the options set for each service in this run
+var installOptions = make(map[string]InstallOptions)
+
+// usedSettings tracks which settings a plan read, so unknown keys can be reported
+var usedSettings = make(map[string]map[string]bool)
+
+// SetInstallOptions sets the version and settings used when building a service's plan
+func SetInstallOptions(serviceName string, options InstallOptions) {
+	installOptions[serviceName] = options
+}
+
+// SetSetting sets one of a service's settings for this run, as a stack file
+// would, keeping the ones set before
+func SetSetting(serviceName, key, value string) {
+	options := installOptions[serviceName]
+	if options.Settings == nil {
+		options.Settings = make(map[string]string)
+	}
+	options.Settings[key] = value
+	installOptions[serviceName] = options
+}
+
+// requestedVersion returns the version asked for on the command line or in
+// a stack file, or "" to let the plan choose
+func requestedVersion(serviceName string) string {
+	return installOptions[serviceName].Version
+}
+
+// setting returns a per-service setting, or fallback when it was not given
+func setting(serviceName, key, fallback string) string {
+	if usedSettings[serviceName] == nil {
+		usedSettings[serviceName] = make(map[string]bool)
+	}
+	usedSettings[serviceName][key] = true
+
+	if value, ok := installOptions[serviceName].Settings[key]; ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// settingBool answers a yes/no question from a per-service setting when one
+// was given, and asks the user otherwise
+func settingBool(serviceName, key, question string) bool {
+	switch strings.ToLower(setting(serviceName, key, "")) {
+	case "true", "yes", "y", "1":
+		return true
+	case "false", "no", "n", "0":
+		return false
+	}
+	return utils.AskYesNo(question)
+}
+
+// checkOptions makes sure the plan was built for the requested version and
+// warns about settings the service does not understand. Whether the version
+// that ends up installed matches is up to the plan.
+func checkOptions(plan *Plan) error {
+	options := installOptions[plan.Service]
+
+	if options.Version != "" && normalizeVersion(options.Version) != normalizeVersion(plan.Version) {
+		if plan.Version == "" {
+			return fmt.Errorf("%s does not support choosing a version", plan.Service)
+		}
+		return fmt.Errorf("%s %s was requested but bootup installs %s", plan.Service, options.Version, plan.Version)
+	}
+
+	var unknown []string
+	for key := range options.Settings {
+		if usedSettings[plan.Service][key] {
+			continue
+		}
+		// A port the plan cannot change would be silently left at its default
+		if _, ok := registeredPort(plan.Service, key); ok {
+			return fmt.Errorf("%s does not support changing its %s", plan.Service, key)
+		}
+		unknown = append(unknown, key)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		utils.PrintWarning(fmt.Sprintf("Ignoring unknown %s settings: %s", plan.Service, strings.Join(unknown, ", ")))
+	}
+	return nil
+}
+
+// normalizeVersion strips the prefixes used by the different version lists,
+// so "go1.22.3" matches "1.22.3" and "php8.3" matches "8.3"
+func normalizeVersion(version string) string {
+	for _, prefix := range []string{"go", "php", "python", "v"} {
+		version = strings.TrimPrefix(version, prefix)
+	}
+	return version
+}
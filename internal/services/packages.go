@@ -0,0 +1,537 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// Distro describes how a service is installed from the packages of one
+// distribution or distro family
+type Distro struct {
+	Repos     []PackageRepo
+	Packages  []string // the first package carries the service's version
+	Conflicts []string // packages removed first when present
+	Setup     []Step   // run once the packages are installed, before the units start
+	Units     []string // services enabled and started
+
+	// Releases lists the releases upstream publishes packages for, as
+	// codenames on the Debian family and major versions elsewhere. Any
+	// release of the distribution is accepted when empty.
+	Releases []string
+}
+
+// PackageRepo is a third-party package repository. Content and Key may use
+// the {id}, {codename}, {version_id} and {arch} placeholders of the running
+// system, where {id} is the distribution it is based on and {arch} is the
+// Debian architecture name.
+type PackageRepo struct {
+	Name    string
+	File    string // apt source list or yum .repo file
+	Content string
+
+	// Key is the URL of the signing key apt stores in Keyring. yum .repo
+	// files name their key in Content instead.
+	Key     string
+	Keyring string
+	Armored bool // keep the key ASCII-armored instead of converting it to a keyring
+}
+
+// packageRegistry lists, per service, how it is installed on each supported
+// distribution. Entries are keyed by distribution ID (ubuntu, debian) or by
+// family (debian, rhel, alpine, arch); the running system's ID is tried
+// before the IDs it is like and finally its family.
+var packageRegistry = map[string]map[string]Distro{
+	"nginx": {
+		"debian": {Packages: []string{"nginx", "apache2-utils"}, Units: []string{"nginx"}},
+		"rhel":   {Packages: []string{"nginx", "httpd-tools"}, Units: []string{"nginx"}},
+		"alpine": {Packages: []string{"nginx", "apache2-utils"}, Units: []string{"nginx"}},
+		"arch":   {Packages: []string{"nginx"}, Units: []string{"nginx"}},
+	},
+	"caddy": {
+		"debian": {
+			Repos: []PackageRepo{{
+				Name:    "Caddy",
+				File:    "/etc/apt/sources.list.d/caddy-stable.list",
+				Content: "deb [signed-by=/usr/share/keyrings/caddy-stable-archive-keyring.gpg] https://dl.cloudsmith.io/public/caddy/stable/deb/debian any-version main\n",
+				Key:     "https://dl.cloudsmith.io/public/caddy/stable/gpg.key",
+				Keyring: "/usr/share/keyrings/caddy-stable-archive-keyring.gpg",
+			}},
+			Packages: []string{"caddy"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "Caddy",
+				File: "/etc/yum.repos.d/caddy.repo",
+				Content: `[copr:copr.fedorainfracloud.org:group_caddy:caddy]
+name=Copr repo for caddy owned by @caddy
+baseurl=https://download.copr.fedorainfracloud.org/results/@caddy/caddy/epel-$releasever-$basearch/
+type=rpm-md
+gpgcheck=1
+gpgkey=https://download.copr.fedorainfracloud.org/results/@caddy/caddy/pubkey.gpg
+enabled=1
+`,
+			}},
+			Packages: []string{"caddy"},
+		},
+		"alpine": {Packages: []string{"caddy"}},
+		"arch":   {Packages: []string{"caddy"}},
+	},
+	"postgresql": {
+		"debian": {Packages: []string{"postgresql", "postgresql-contrib"}, Units: []string{"postgresql"}},
+		"rhel": {
+			Packages: []string{"postgresql-server", "postgresql-contrib"},
+			Setup:    []Step{command("Initializing the database cluster", "sudo", "postgresql-setup", "--initdb").optional()},
+			Units:    []string{"postgresql"},
+		},
+		// The OpenRC service initializes the cluster on first start
+		"alpine": {Packages: []string{"postgresql", "postgresql-contrib"}, Units: []string{"postgresql"}},
+		"arch": {
+			Packages: []string{"postgresql"},
+			Setup:    []Step{command("Initializing the database cluster", "sudo", "-u", "postgres", "initdb", "-D", "/var/lib/postgres/data").optional()},
+			Units:    []string{"postgresql"},
+		},
+	},
+	"mongodb": {
+		"ubuntu": {
+			Repos: []PackageRepo{{
+				Name:    "MongoDB",
+				File:    "/etc/apt/sources.list.d/mongodb-org-8.2.list",
+				Content: "deb [ arch=amd64,arm64 signed-by=/usr/share/keyrings/mongodb-server-8.0.gpg ] https://repo.mongodb.org/apt/ubuntu {codename}/mongodb-org/8.2 multiverse\n",
+				Key:     "https://www.mongodb.org/static/pgp/server-8.0.asc",
+				Keyring: "/usr/share/keyrings/mongodb-server-8.0.gpg",
+			}},
+			Packages: []string{"mongodb-org"},
+			Units:    []string{"mongod"},
+			Releases: []string{"jammy", "noble"},
+		},
+		"debian": {
+			Repos: []PackageRepo{{
+				Name:    "MongoDB",
+				File:    "/etc/apt/sources.list.d/mongodb-org-8.2.list",
+				Content: "deb [ signed-by=/usr/share/keyrings/mongodb-server-8.0.gpg ] https://repo.mongodb.org/apt/debian {codename}/mongodb-org/8.2 main\n",
+				Key:     "https://www.mongodb.org/static/pgp/server-8.0.asc",
+				Keyring: "/usr/share/keyrings/mongodb-server-8.0.gpg",
+			}},
+			Packages: []string{"mongodb-org"},
+			Units:    []string{"mongod"},
+			Releases: []string{"bookworm"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "MongoDB",
+				File: "/etc/yum.repos.d/mongodb-org-8.2.repo",
+				Content: `[mongodb-org-8.2]
+name=MongoDB Repository
+baseurl=https://repo.mongodb.org/yum/redhat/$releasever/mongodb-org/8.2/$basearch/
+gpgcheck=1
+enabled=1
+gpgkey=https://pgp.mongodb.com/server-8.0.asc
+`,
+			}},
+			Packages: []string{"mongodb-org"},
+			Units:    []string{"mongod"},
+			Releases: []string{"8", "9"},
+		},
+	},
+	"redis": {
+		"debian": {Packages: []string{"redis-server"}, Units: []string{"redis-server"}},
+		"rhel":   {Packages: []string{"redis"}, Units: []string{"redis"}},
+		"alpine": {Packages: []string{"redis"}, Units: []string{"redis"}},
+	},
+	"elasticsearch": {
+		"debian": {
+			Repos: []PackageRepo{{
+				Name:    "Elasticsearch",
+				File:    "/etc/apt/sources.list.d/elastic-9.x.list",
+				Content: "deb [signed-by=/usr/share/keyrings/elasticsearch-keyring.gpg] https://artifacts.elastic.co/packages/9.x/apt stable main\n",
+				Key:     "https://artifacts.elastic.co/GPG-KEY-elasticsearch",
+				Keyring: "/usr/share/keyrings/elasticsearch-keyring.gpg",
+			}},
+			Packages: []string{"elasticsearch"},
+			Setup:    []Step{command("Reloading systemd daemon", "sudo", "systemctl", "daemon-reload")},
+			Units:    []string{"elasticsearch.service"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "Elasticsearch",
+				File: "/etc/yum.repos.d/elasticsearch.repo",
+				Content: `[elasticsearch]
+name=Elasticsearch repository for 9.x packages
+baseurl=https://artifacts.elastic.co/packages/9.x/yum
+gpgcheck=1
+gpgkey=https://artifacts.elastic.co/GPG-KEY-elasticsearch
+enabled=1
+type=rpm-md
+`,
+			}},
+			Packages: []string{"elasticsearch"},
+			Setup:    []Step{command("Reloading systemd daemon", "sudo", "systemctl", "daemon-reload")},
+			Units:    []string{"elasticsearch.service"},
+		},
+	},
+	"mysql": {
+		"debian": {Packages: []string{"mysql-server"}, Units: []string{"mysql"}},
+		"rhel":   {Packages: []string{"mysql-server"}, Units: []string{"mysqld"}},
+	},
+	"clickhouse": {
+		"debian": {
+			Repos: []PackageRepo{{
+				Name:    "ClickHouse",
+				File:    "/etc/apt/sources.list.d/clickhouse.list",
+				Content: "deb [signed-by=/usr/share/keyrings/clickhouse-keyring.gpg] https://packages.clickhouse.com/deb stable main\n",
+				Key:     "https://packages.clickhouse.com/rpm/lts/repodata/repomd.xml.key",
+				Keyring: "/usr/share/keyrings/clickhouse-keyring.gpg",
+			}},
+			Packages: []string{"clickhouse-server", "clickhouse-client"},
+			Units:    []string{"clickhouse-server"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "ClickHouse",
+				File: "/etc/yum.repos.d/clickhouse.repo",
+				Content: `[clickhouse-stable]
+name=ClickHouse - Stable Repository
+baseurl=https://packages.clickhouse.com/rpm/stable/
+gpgkey=https://packages.clickhouse.com/rpm/stable/repodata/repomd.xml.key
+gpgcheck=0
+repo_gpgcheck=1
+enabled=1
+`,
+			}},
+			Packages: []string{"clickhouse-server", "clickhouse-client"},
+			Units:    []string{"clickhouse-server"},
+		},
+	},
+	"nodejs": {
+		// The NodeSource repository depends on the chosen major version and
+		// is added by the Node.js plan itself
+		"debian": {Packages: []string{"nodejs"}},
+		"rhel":   {Packages: []string{"nodejs"}},
+	},
+	"java": {
+		"debian": {Packages: []string{"openjdk-17-jdk"}},
+		"rhel":   {Packages: []string{"java-17-openjdk-devel"}},
+		"alpine": {Packages: []string{"openjdk17"}},
+		"arch":   {Packages: []string{"jdk17-openjdk"}},
+	},
+	"rabbitmq": {
+		"debian": {
+			Repos: []PackageRepo{
+				{
+					Name:    "RabbitMQ team",
+					Key:     "https://keys.openpgp.org/vks/v1/by-fingerprint/0A9AF2115F4687BD29803A206B73A36E6026DFCA",
+					Keyring: "/usr/share/keyrings/com.rabbitmq.team.gpg",
+				},
+				{
+					Name:    "Erlang Solutions",
+					Key:     "https://github.com/rabbitmq/signing-keys/releases/download/3.0/cloudsmith.rabbitmq-erlang.E495BB49CC4BBE5B.key",
+					Keyring: "/usr/share/keyrings/io.cloudsmith.rabbitmq.E495BB49CC4BBE5B.gpg",
+				},
+				{
+					Name: "RabbitMQ",
+					File: "/etc/apt/sources.list.d/rabbitmq.list",
+					Content: `## Provides modern Erlang/OTP releases
+deb [arch={arch} signed-by=/usr/share/keyrings/io.cloudsmith.rabbitmq.E495BB49CC4BBE5B.gpg] https://dl.cloudsmith.io/public/rabbitmq/rabbitmq-erlang/deb/{id} {codename} main
+deb-src [arch={arch} signed-by=/usr/share/keyrings/io.cloudsmith.rabbitmq.E495BB49CC4BBE5B.gpg] https://dl.cloudsmith.io/public/rabbitmq/rabbitmq-erlang/deb/{id} {codename} main
+
+## Provides RabbitMQ
+deb [arch={arch} signed-by=/usr/share/keyrings/io.cloudsmith.rabbitmq.9F4587F226208342.gpg] https://dl.cloudsmith.io/public/rabbitmq/rabbitmq-server/deb/{id} {codename} main
+deb-src [arch={arch} signed-by=/usr/share/keyrings/io.cloudsmith.rabbitmq.9F4587F226208342.gpg] https://dl.cloudsmith.io/public/rabbitmq/rabbitmq-server/deb/{id} {codename} main
+`,
+					Key:     "https://github.com/rabbitmq/signing-keys/releases/download/3.0/cloudsmith.rabbitmq-server.9F4587F226208342.key",
+					Keyring: "/usr/share/keyrings/io.cloudsmith.rabbitmq.9F4587F226208342.gpg",
+				},
+			},
+			Packages: append([]string{"rabbitmq-server"}, rabbitmqErlangPackages...),
+			Units:    []string{"rabbitmq-server"},
+			Releases: []string{"jammy", "noble", "bullseye", "bookworm", "trixie"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "RabbitMQ",
+				File: "/etc/yum.repos.d/rabbitmq.repo",
+				Content: `[rabbitmq-erlang]
+name=rabbitmq-erlang
+baseurl=https://yum1.rabbitmq.com/erlang/el/$releasever/$basearch
+repo_gpgcheck=1
+gpgcheck=1
+enabled=1
+gpgkey=https://github.com/rabbitmq/signing-keys/releases/download/3.0/cloudsmith.rabbitmq-erlang.E495BB49CC4BBE5B.key
+
+[rabbitmq-server]
+name=rabbitmq-server
+baseurl=https://yum1.rabbitmq.com/rabbitmq/el/$releasever/noarch
+repo_gpgcheck=1
+gpgcheck=1
+enabled=1
+gpgkey=https://github.com/rabbitmq/signing-keys/releases/download/3.0/cloudsmith.rabbitmq-server.9F4587F226208342.key
+       https://github.com/rabbitmq/signing-keys/releases/download/3.0/rabbitmq-release-signing-key.asc
+`,
+			}},
+			Packages: []string{"rabbitmq-server", "erlang"},
+			Units:    []string{"rabbitmq-server"},
+			Releases: []string{"8", "9"},
+		},
+		"alpine": {Packages: []string{"rabbitmq-server"}, Units: []string{"rabbitmq-server"}},
+	},
+	"grafana": {
+		"debian": {
+			Repos: []PackageRepo{{
+				Name:    "Grafana",
+				File:    "/etc/apt/sources.list.d/grafana.list",
+				Content: "deb [signed-by=/etc/apt/keyrings/grafana.gpg] https://apt.grafana.com stable main\n",
+				Key:     "https://apt.grafana.com/gpg.key",
+				Keyring: "/etc/apt/keyrings/grafana.gpg",
+			}},
+			Packages: []string{"grafana"},
+			Setup:    []Step{command("Reloading systemd daemon", "sudo", "systemctl", "daemon-reload")},
+			Units:    []string{"grafana-server"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "Grafana",
+				File: "/etc/yum.repos.d/grafana.repo",
+				Content: `[grafana]
+name=grafana
+baseurl=https://rpm.grafana.com
+repo_gpgcheck=1
+enabled=1
+gpgcheck=1
+gpgkey=https://rpm.grafana.com/gpg.key
+sslverify=1
+`,
+			}},
+			Packages: []string{"grafana"},
+			Setup:    []Step{command("Reloading systemd daemon", "sudo", "systemctl", "daemon-reload")},
+			Units:    []string{"grafana-server"},
+		},
+		"alpine": {Packages: []string{"grafana"}, Units: []string{"grafana"}},
+		"arch":   {Packages: []string{"grafana"}, Units: []string{"grafana"}},
+	},
+	"docker": {
+		"debian": {
+			Repos: []PackageRepo{{
+				Name: "Docker",
+				File: "/etc/apt/sources.list.d/docker.sources",
+				Content: `Types: deb
+URIs: https://download.docker.com/linux/{id}
+Suites: {codename}
+Components: stable
+Signed-By: /etc/apt/keyrings/docker.asc
+`,
+				Key:     "https://download.docker.com/linux/{id}/gpg",
+				Keyring: "/etc/apt/keyrings/docker.asc",
+				Armored: true,
+			}},
+			Packages:  []string{"docker-ce", "docker-ce-cli", "containerd.io", "docker-buildx-plugin", "docker-compose-plugin"},
+			Conflicts: []string{"docker.io", "docker-compose", "docker-compose-v2", "docker-doc", "podman-docker", "containerd", "runc"},
+			Units:     []string{"docker"},
+			Releases:  []string{"jammy", "noble", "plucky", "bullseye", "bookworm", "trixie"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "Docker",
+				File: "/etc/yum.repos.d/docker-ce.repo",
+				Content: `[docker-ce-stable]
+name=Docker CE Stable - $basearch
+baseurl=https://download.docker.com/linux/rhel/$releasever/$basearch/stable
+enabled=1
+gpgcheck=1
+gpgkey=https://download.docker.com/linux/rhel/gpg
+`,
+			}},
+			Packages:  []string{"docker-ce", "docker-ce-cli", "containerd.io", "docker-buildx-plugin", "docker-compose-plugin"},
+			Conflicts: []string{"docker", "docker-client", "docker-common", "docker-latest", "docker-engine", "podman", "runc"},
+			Units:     []string{"docker"},
+			Releases:  []string{"8", "9", "10"},
+		},
+		"alpine": {Packages: []string{"docker", "docker-cli-compose"}, Units: []string{"docker"}},
+		"arch":   {Packages: []string{"docker", "docker-compose"}, Units: []string{"docker"}},
+	},
+	// PHP and Python packages are named after the chosen version, so these
+	// entries only record where the PPAs the plans add are published. Other
+	// Debian releases install the versions Debian ships.
+	"php": {
+		"ubuntu": {Releases: []string{"jammy", "noble"}},
+		"debian": {},
+	},
+	"python": {
+		"ubuntu": {Releases: []string{"jammy", "noble"}},
+		"debian": {},
+	},
+	"trivy": {
+		"debian": {
+			Repos: []PackageRepo{{
+				Name:    "Trivy",
+				File:    "/etc/apt/sources.list.d/trivy.list",
+				Content: "deb [signed-by=/usr/share/keyrings/trivy.gpg] https://aquasecurity.github.io/trivy-repo/deb generic main\n",
+				Key:     "https://aquasecurity.github.io/trivy-repo/deb/public.key",
+				Keyring: "/usr/share/keyrings/trivy.gpg",
+			}},
+			Packages: []string{"trivy"},
+		},
+		"rhel": {
+			Repos: []PackageRepo{{
+				Name: "Trivy",
+				File: "/etc/yum.repos.d/trivy.repo",
+				Content: `[trivy]
+name=Trivy repository
+baseurl=https://aquasecurity.github.io/trivy-repo/rpm/releases/$basearch/
+gpgcheck=1
+enabled=1
+gpgkey=https://aquasecurity.github.io/trivy-repo/rpm/public.key
+`,
+			}},
+			Packages: []string{"trivy"},
+		},
+		"alpine": {Packages: []string{"trivy"}},
+		"arch":   {Packages: []string{"trivy"}},
+	},
+}
+
+// packageManager returns the package manager of the running distribution
+func packageManager() (platform.PackageManager, error) {
+	host, err := platform.Detect()
+	if err != nil {
+		return nil, err
+	}
+	return host.PackageManager()
+}
+
+// distroFor returns how a service is installed on the running distribution
+func distroFor(serviceName string) (Distro, *platform.OS, error) {
+	host, err := platform.Detect()
+	if err != nil {
+		return Distro{}, nil, err
+	}
+
+	distros := packageRegistry[serviceName]
+	for _, key := range slices.Concat([]string{host.ID}, host.IDLike, []string{string(host.Family)}) {
+		distro, ok := distros[key]
+		if !ok {
+			continue
+		}
+		if len(distro.Releases) > 0 && !slices.Contains(distro.Releases, host.Release()) {
+			return Distro{}, host, fmt.Errorf("%s is not published for %s, supported releases are %s",
+				serviceName, host, strings.Join(distro.Releases, ", "))
+		}
+		return distro, host, nil
+	}
+	return Distro{}, host, fmt.Errorf("%s is not available on %s", serviceName, host)
+}
+
+// servicePackages returns the packages of a service on the running
+// distribution, or nil when it is not installed from packages there
+func servicePackages(serviceName string) []string {
+	distro, _, err := distroFor(serviceName)
+	if err != nil {
+		return nil
+	}
+	return distro.Packages
+}
+
+// packagePlan starts the plan of a service installed from distribution
+// packages: it adds the service's repositories, installs its packages, runs
+// its setup and enables its units
+func packagePlan(serviceName, title string) (*Plan, error) {
+	distro, host, err := distroFor(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := NewPlan(serviceName)
+	if len(distro.Conflicts) > 0 {
+		pm, err := host.PackageManager()
+		if err != nil {
+			return nil, err
+		}
+		remove := pm.RemoveCommand(distro.Conflicts, false)
+		plan.Add(command("Removing any conflicting packages", remove[0], remove[1:]...).optional())
+	}
+	if len(distro.Repos) > 0 {
+		if host.Family == platform.Debian {
+			plan.Add(packageInstall("Installing prerequisites", "ca-certificates", "curl", "gnupg"))
+		}
+		arch, err := platform.DetectArch()
+		if err != nil {
+			return nil, err
+		}
+		plan.Add(repoSteps(distro.Repos, host, arch)...)
+	}
+	plan.Add(
+		packageUpdate(),
+		packageInstall(fmt.Sprintf("Installing %s", title), distro.Packages...),
+	)
+	plan.Add(distro.Setup...)
+	if len(distro.Units) > 0 {
+		plan.Add(enableStart(distro.Units...))
+	}
+	return plan, nil
+}
+
+// repoSteps adds the signing keys and repository files of repos
+func repoSteps(repos []PackageRepo, host *platform.OS, arch platform.Arch) []Step {
+	expand := strings.NewReplacer(
+		"{id}", host.Base,
+		"{codename}", host.Codename,
+		"{version_id}", host.VersionID,
+		"{arch}", arch.Debian,
+	).Replace
+
+	var steps []Step
+	for _, repo := range repos {
+		if repo.Key != "" {
+			description := fmt.Sprintf("Adding %s signing key", repo.Name)
+			if repo.Armored {
+				steps = append(steps, aptKeyFile(description, expand(repo.Key), repo.Keyring))
+			} else {
+				steps = append(steps, aptKey(description, expand(repo.Key), repo.Keyring))
+			}
+		}
+		if repo.File != "" {
+			steps = append(steps, repoFile(fmt.Sprintf("Adding %s repository", repo.Name), repo.File, expand(repo.Content)))
+		}
+	}
+	return steps
+}
+
+// packageUninstallPlan removes a service installed with packagePlan. The
+// units, packages and repositories come from the registry; spec adds the
+// service's data directories and anything else it left behind.
+func packageUninstallPlan(serviceName string, spec uninstallSpec, purge bool) (*Plan, error) {
+	distro, _, err := distroFor(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.units = appendUnique(spec.units, distro.Units...)
+	spec.packages = appendUnique(spec.packages, distro.Packages...)
+	for _, repo := range distro.Repos {
+		for _, file := range []string{repo.File, repo.Keyring} {
+			if file != "" {
+				spec.files = appendUnique(spec.files, file)
+			}
+		}
+	}
+	return uninstallPlan(serviceName, spec, purge), nil
+}
+
+// serviceCommand returns a step that starts, stops or restarts a service with
+// the running system's init system
+func serviceCommand(description, verb, unit string) Step {
+	host, err := platform.Detect()
+	if err != nil {
+		return command(description, "sudo", "systemctl", verb, unit)
+	}
+	argv := host.ServiceCommand(verb, unit)
+	return command(description, argv[0], argv[1:]...)
+}
+
+// runCommand runs a command given as a single argument list
+func runCommand(argv []string) error {
+	return utils.RunCommand(argv[0], argv[1:]...)
+}
@@ -1,52 +1,32 @@
 package services
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
-func InstallPHP() error {
-	utils.PrintInfo("Installing PHP...")
+// Composer publishes the SHA-384 of its installer next to it
+const (
+	composerInstallerURL = "https://getcomposer.org/installer"
+	composerSignatureURL = "https://composer.github.io/installer.sig"
+	composerInstaller    = "/tmp/composer-setup.php"
+)
 
-	// Check if running on supported OS
-	if runtime.GOOS != "linux" {
-		return fmt.Errorf("PHP installation is currently only supported on Linux")
+// PHPPlan asks which PHP version to install and builds its install plan
+func PHPPlan() (*Plan, error) {
+	_, host, err := distroFor("php")
+	if err != nil {
+		return nil, err
 	}
-
-	// For now, assume Ubuntu/Debian (like Docker service)
-	// Can be extended later for other distributions
-	return installPHPDebian()
+	return phpDebianPlan(host.Base == "ubuntu")
 }
 
-func installPHPDebian() error {
-	utils.PrintInfo("Installing PHP on Ubuntu/Debian...")
-
-	// Update package lists
-	if err := utils.RunCommand("sudo", "apt", "update"); err != nil {
-		return fmt.Errorf("failed to update package lists: %w", err)
-	}
-
-	// Add Ondřej Surý's PPA for additional PHP versions (Ubuntu only)
-	utils.PrintInfo("Adding PHP PPA for more version options...")
-	if err := utils.RunCommand("sudo", "apt", "install", "-y", "software-properties-common"); err != nil {
-		utils.PrintWarning("Failed to install software-properties-common, continuing with default repositories")
-	} else {
-		if err := utils.RunCommand("sudo", "add-apt-repository", "-y", "ppa:ondrej/php"); err != nil {
-			utils.PrintWarning("Failed to add PHP PPA, using default repositories")
-		} else {
-			// Update package lists after adding PPA
-			if err := utils.RunCommand("sudo", "apt", "update"); err != nil {
-				return fmt.Errorf("failed to update package lists after adding PPA: %w", err)
-			}
-		}
-	}
-
+// phpDebianPlan installs PHP from the Debian packages, adding the
+// PHP PPA for versions the release does not ship on Ubuntu
+func phpDebianPlan(withPPA bool) (*Plan, error) {
 	// Display available PHP versions
 	versions := []string{
 		"php8.3",
@@ -56,36 +36,78 @@ func installPHPDebian() error {
 		"php7.4",
 	}
 
-	selectedVersion, err := displayAndSelectPHPVersion(versions)
-	if err != nil {
-		return fmt.Errorf("failed to select version: %w", err)
+	selectedVersion := "php" + normalizeVersion(requestedVersion("php"))
+	if selectedVersion == "php" {
+		var err error
+		selectedVersion, err = displayAndSelectPHPVersion(versions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select version: %w", err)
+		}
 	}
 
 	// Install PHP and common extensions
-	packages := []string{
-		selectedVersion,
-		selectedVersion + "-cli",
-		selectedVersion + "-common",
-		selectedVersion + "-curl",
-		selectedVersion + "-gd",
-		selectedVersion + "-mbstring",
-		selectedVersion + "-mysql",
-		selectedVersion + "-xml",
-		selectedVersion + "-zip",
-		selectedVersion + "-fpm",
-		selectedVersion + "-opcache",
-		selectedVersion + "-intl",
-		selectedVersion + "-bcmath",
+	packages := phpPackages(selectedVersion)
+
+	plan := NewPlan("php")
+	plan.Version = strings.TrimPrefix(selectedVersion, "php")
+	plan.Add(packageUpdate())
+	if withPPA {
+		// Add Ondřej Surý's PPA for additional PHP versions
+		plan.Add(
+			packageInstall("Installing software-properties-common", "software-properties-common").optional(),
+			aptPPA("Adding PHP PPA", "ppa:ondrej/php").optional(),
+			packageUpdate(),
+		)
 	}
+	plan.Add(
+		packageInstall(fmt.Sprintf("Installing %s and common extensions", selectedVersion), packages...),
+		// Install Composer
+		download("Downloading Composer installer", composerInstallerURL, composerInstaller, composerSignatureURL, func() (string, error) {
+			return utils.FetchChecksum(composerSignatureURL, "composer-setup.php")
+		}),
+		command("Installing Composer", "sudo", "php", composerInstaller, "--install-dir=/usr/local/bin", "--filename=composer"),
+		command("Removing Composer installer", "rm", "-f", composerInstaller).optional(),
+	)
+	plan.Success = "PHP and Composer installed successfully!"
+	plan.Note(
+		"You can verify the installation with:",
+		"  php --version",
+		"  composer --version",
+	)
+	return plan, nil
+}
 
-	utils.PrintInfo(fmt.Sprintf("Installing %s and common extensions...", selectedVersion))
-	args := append([]string{"apt", "install", "-y"}, packages...)
-	if err := utils.RunCommand("sudo", args...); err != nil {
-		return fmt.Errorf("failed to install PHP: %w", err)
+// PHPUninstallPlan builds the removal plan for the installed PHP version and Composer
+func PHPUninstallPlan(purge bool) (*Plan, error) {
+	version := ""
+	if record, ok := GetInstallRecord("php"); ok {
+		version = record.Version
 	}
+	if version == "" {
+		detected, err := utils.CommandOutput("php", "-r", `echo PHP_MAJOR_VERSION.".".PHP_MINOR_VERSION;`)
+		if err != nil || detected == "" {
+			return nil, fmt.Errorf("failed to detect the installed PHP version: %w", err)
+		}
+		version = detected
+	}
+
+	plan := uninstallPlan("php", uninstallSpec{
+		units:    []string{"php" + version + "-fpm"},
+		packages: phpPackages("php" + version),
+		files:    []string{"/usr/local/bin/composer"},
+		dataDirs: []string{"/etc/php/" + version},
+	}, purge)
+	plan.Add(command("Removing PHP PPA", "sudo", "add-apt-repository", "--remove", "-y", "ppa:ondrej/php").optional())
+	return plan, nil
+}
 
-	// Install Composer
-	return installComposer()
+// phpPackages returns the PHP package and the common extensions installed with it
+func phpPackages(version string) []string {
+	packages := []string{version}
+	for _, ext := range []string{"cli", "common", "curl", "gd", "mbstring", "mysql", "xml", "zip", "fpm", "opcache", "intl", "bcmath"} {
+		packages = append(packages, version+"-"+ext)
+	}
+	return packages
 }
 
 func displayAndSelectPHPVersion(versions []string) (string, error) {
@@ -93,14 +115,18 @@ func displayAndSelectPHPVersion(versions []string) (string, error) {
 		return "", fmt.Errorf("no PHP versions available")
 	}
 
+	if utils.AssumeYes() {
+		fmt.Printf("✅ Selected: %s (default)\n", versions[0])
+		return versions[0], nil
+	}
+
 	utils.PrintInfo("Available PHP versions:")
 	for i, version := range versions {
 		fmt.Printf("%d. %s\n", i+1, version)
 	}
 
-	reader := bufio.NewReader(os.Stdin)
 	fmt.Print("Select a version (default: 1): ")
-	input, err := reader.ReadString('\n')
+	input, err := utils.Stdin.ReadString('\n')
 	if err != nil {
 		return "", err
 	}
@@ -117,29 +143,3 @@ func displayAndSelectPHPVersion(versions []string) (string, error) {
 
 	return versions[choice-1], nil
 }
-
-func installComposer() error {
-	utils.PrintInfo("Installing Composer...")
-
-	// Download Composer installer
-	if err := utils.RunCommand("curl", "-sS", "https://getcomposer.org/installer", "-o", "composer-setup.php"); err != nil {
-		return fmt.Errorf("failed to download Composer installer: %w", err)
-	}
-
-	// Install Composer
-	if err := utils.RunCommand("sudo", "php", "composer-setup.php", "--install-dir=/usr/local/bin", "--filename=composer"); err != nil {
-		return fmt.Errorf("failed to install Composer: %w", err)
-	}
-
-	// Clean up installer
-	if err := utils.RunCommand("rm", "composer-setup.php"); err != nil {
-		utils.PrintWarning("Failed to remove Composer installer")
-	}
-
-	utils.PrintSuccess("PHP and Composer installed successfully!")
-	utils.PrintInfo("You can verify the installation with:")
-	utils.PrintInfo("  php --version")
-	utils.PrintInfo("  composer --version")
-
-	return nil
-}
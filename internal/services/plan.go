@@ -0,0 +1,503 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// StepKind identifies what an install step does to the host
+type StepKind string
+
+const (
+	StepAptKey         StepKind = "apt-key"
+	StepRepo           StepKind = "repo"
+	StepAptPPA         StepKind = "apt-ppa"
+	StepPackageUpdate  StepKind = "package-update"
+	StepPackageInstall StepKind = "package-install"
+	StepCreateUser     StepKind = "create-user"
+	StepDirectory      StepKind = "directory"
+	StepDownload       StepKind = "download"
+	StepExtract        StepKind = "extract"
+	StepWriteFile      StepKind = "write-file"
+	StepSystemdUnit    StepKind = "systemd-unit"
+	StepEnableStart    StepKind = "enable-start"
+	StepCommand        StepKind = "command"
+	StepVerify         StepKind = "verify"
+
+	// Steps used by uninstall plans
+	StepStopDisable   StepKind = "stop-disable"
+	StepRemoveUnit    StepKind = "remove-unit"
+	StepPackageRemove StepKind = "package-remove"
+	StepRemove        StepKind = "remove"
+	StepDeleteUser    StepKind = "delete-user"
+)
+
+// Step is a single declarative action within an install plan. Only the
+// fields relevant to the step's Kind are set.
+type Step struct {
+	Kind        StepKind
+	Description string
+
+	URL      string      // apt-key, download
+	Checksum string      // download: where the expected checksum comes from
+	Path     string      // apt-key keyring, repo file, directory, download target, extract destination, written file
+	Source   string      // extract archive
+	Strip    int         // extract --strip-components
+	Content  string      // repo, write-file and systemd-unit contents
+	Mode     os.FileMode // write-file permissions
+	Owner    string      // directory owner (user:group)
+	Dearmor  bool        // apt-key: convert an ASCII-armored key to a binary keyring
+	PPA      string      // apt-ppa
+	Packages []string    // package-install, package-remove
+	Purge    bool        // package-remove: also delete package configuration
+	Paths    []string    // remove
+	Units    []string    // systemd-unit, enable-start, stop-disable, remove-unit
+	User     string      // create-user, delete-user
+	Command  []string    // command, verify
+	Shell    string      // command, verify run through bash
+
+	// Optional steps only print a warning when they fail
+	Optional bool
+
+	run func() error
+
+	// afterUndo runs when the step is rolled back, after its own undo
+	afterUndo func() error
+
+	// checksum resolves the expected digest of a download when the step runs
+	checksum func() (string, error)
+}
+
+// Plan is the ordered list of steps that installs a service
+type Plan struct {
+	Service string
+	Version string
+	Steps   []Step
+
+	// Config holds the settings the plan was built with, as recorded in the state file
+	Config map[string]string
+
+	// Success and Notes are printed once every step has completed
+	Success string
+	Notes   []string
+}
+
+// NewPlan returns an empty plan for the given service
+func NewPlan(service string) *Plan {
+	return &Plan{Service: service}
+}
+
+// Add appends steps to the plan
+func (p *Plan) Add(steps ...Step) *Plan {
+	p.Steps = append(p.Steps, steps...)
+	return p
+}
+
+// Note appends a message shown after a successful install
+func (p *Plan) Note(notes ...string) *Plan {
+	p.Notes = append(p.Notes, notes...)
+	return p
+}
+
+// Execute runs every step in order, stopping at the first required step that
+// fails. Unless rollback is disabled, the changes made by the steps that
+// already ran are then undone in reverse order.
+func (p *Plan) Execute() error {
+	var undos []*undoAction
+
+	total := len(p.Steps)
+	for i, step := range p.Steps {
+		utils.PrintInfo(fmt.Sprintf("[%d/%d] %s...", i+1, total, step.Description))
+		undo := step.prepareUndo()
+		if err := step.Apply(); err != nil {
+			if step.Optional {
+				utils.PrintWarning(fmt.Sprintf("%s failed, continuing: %v", step.Description, err))
+				continue
+			}
+			// The failed step may have been partially applied, so undo it too
+			if undo != nil {
+				undos = append(undos, undo)
+			}
+			if rollbackEnabled {
+				rollback(undos)
+			} else if len(undos) > 0 {
+				utils.PrintWarning("Rollback disabled, the changes made so far were left in place")
+			}
+			return fmt.Errorf("%s failed: %w", step.Description, err)
+		}
+		if undo != nil {
+			undos = append(undos, undo)
+		}
+	}
+
+	if p.Success != "" {
+		utils.PrintSuccess(p.Success)
+	}
+	for _, note := range p.Notes {
+		utils.PrintInfo(note)
+	}
+	return nil
+}
+
+// packageListsFresh is set once the package lists were refreshed and cleared
+// whenever a repository is added, so installing several services only
+// refreshes them when something changed
+var packageListsFresh bool
+
+// Apply performs the step on the host through the active utils executor
+func (s Step) Apply() error {
+	switch s.Kind {
+	case StepAptKey, StepRepo, StepAptPPA:
+		packageListsFresh = false
+	}
+
+	switch s.Kind {
+	case StepAptKey:
+		if err := utils.RunCommand("sudo", "install", "-m", "0755", "-d", filepath.Dir(s.Path)); err != nil {
+			return err
+		}
+		if s.Dearmor {
+			if err := utils.RunCommandShell(fmt.Sprintf("curl -fsSL '%s' | sudo gpg --dearmor --yes -o %s", s.URL, s.Path)); err != nil {
+				return err
+			}
+		} else if err := utils.RunCommand("sudo", "curl", "-fsSL", s.URL, "-o", s.Path); err != nil {
+			return err
+		}
+		return utils.RunCommand("sudo", "chmod", "a+r", s.Path)
+
+	case StepRepo:
+		return utils.WriteFile(s.Path, s.Content, 0644)
+
+	case StepAptPPA:
+		return utils.RunCommand("sudo", "add-apt-repository", "-y", s.PPA)
+
+	case StepPackageUpdate:
+		if packageListsFresh {
+			utils.PrintInfo("Package lists are already up to date")
+			return nil
+		}
+		pm, err := packageManager()
+		if err != nil {
+			return err
+		}
+		if err := runCommand(pm.UpdateCommand()); err != nil {
+			return err
+		}
+		packageListsFresh = true
+		return nil
+
+	case StepPackageInstall:
+		pm, err := packageManager()
+		if err != nil {
+			return err
+		}
+		return runCommand(pm.InstallCommand(s.Packages))
+
+	case StepCreateUser:
+		if utils.CheckCommand("id", s.User) {
+			return nil
+		}
+		host, err := platform.Detect()
+		if err != nil {
+			return err
+		}
+		for _, argv := range host.UserAddCommands(s.User) {
+			if err := runCommand(argv); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case StepDirectory:
+		if err := utils.RunCommand("sudo", "mkdir", "-p", s.Path); err != nil {
+			return err
+		}
+		if s.Owner != "" {
+			return utils.RunCommand("sudo", "chown", "-R", s.Owner, s.Path)
+		}
+		return nil
+
+	case StepDownload:
+		if s.checksum == nil {
+			return fmt.Errorf("refusing to download %s without a checksum", s.URL)
+		}
+		if utils.IsDryRun() {
+			return utils.DownloadFile(s.URL, s.Path)
+		}
+		expected, err := s.checksum()
+		if err != nil {
+			return fmt.Errorf("failed to get the checksum of %s: %w", s.URL, err)
+		}
+		return utils.DownloadFileVerified(s.URL, s.Path, expected)
+
+	case StepExtract:
+		if err := utils.RunCommand("sudo", "mkdir", "-p", s.Path); err != nil {
+			return err
+		}
+		args := []string{"tar", "-xzf", s.Source, "-C", s.Path}
+		if s.Strip > 0 {
+			args = append(args, fmt.Sprintf("--strip-components=%d", s.Strip))
+		}
+		return utils.RunCommand("sudo", args...)
+
+	case StepWriteFile:
+		return utils.WriteFile(s.Path, s.Content, s.Mode)
+
+	case StepSystemdUnit:
+		if err := requireSystemd(s.Units[0]); err != nil {
+			return err
+		}
+		return utils.CreateSystemdService(s.Units[0], s.Content)
+
+	case StepEnableStart:
+		host, err := platform.Detect()
+		if err != nil {
+			return err
+		}
+		for _, unit := range s.Units {
+			if err := runCommand(host.ServiceCommand("enable", unit)); err != nil {
+				return err
+			}
+			if err := runCommand(host.ServiceCommand("start", unit)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case StepStopDisable:
+		host, err := platform.Detect()
+		if err != nil {
+			return err
+		}
+		for _, unit := range s.Units {
+			if err := runCommand(host.ServiceCommand("stop", unit)); err != nil {
+				return err
+			}
+			if err := runCommand(host.ServiceCommand("disable", unit)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case StepRemoveUnit:
+		for _, unit := range s.Units {
+			if err := utils.RunCommand("sudo", "rm", "-f", fmt.Sprintf("/etc/systemd/system/%s.service", unit)); err != nil {
+				return err
+			}
+		}
+		return utils.RunCommand("sudo", "systemctl", "daemon-reload")
+
+	case StepPackageRemove:
+		pm, err := packageManager()
+		if err != nil {
+			return err
+		}
+		if err := runCommand(pm.RemoveCommand(s.Packages, s.Purge)); err != nil {
+			return err
+		}
+		if autoremove := pm.AutoremoveCommand(); autoremove != nil {
+			return runCommand(autoremove)
+		}
+		return nil
+
+	case StepRemove:
+		return utils.RunCommand("sudo", append([]string{"rm", "-rf"}, s.Paths...)...)
+
+	case StepDeleteUser:
+		if !utils.CheckCommand("id", s.User) {
+			return nil
+		}
+		return removeUser(s.User)
+
+	case StepCommand, StepVerify:
+		if s.run != nil {
+			return s.run()
+		}
+		if s.Shell != "" {
+			return utils.RunCommandShell(s.Shell)
+		}
+		return utils.RunCommand(s.Command[0], s.Command[1:]...)
+	}
+
+	return fmt.Errorf("unknown step kind %q", s.Kind)
+}
+
+// removeUser deletes a user made by a createUser step. BusyBox deluser may
+// leave the group made along with it behind.
+func removeUser(user string) error {
+	host, err := platform.Detect()
+	if err != nil {
+		return err
+	}
+	if err := runCommand(host.UserDelCommand(user)); err != nil {
+		return err
+	}
+	if host.Family == platform.Alpine && utils.CheckCommand("getent", "group", user) {
+		return utils.RunCommand("sudo", "delgroup", user)
+	}
+	return nil
+}
+
+// requireSystemd fails on hosts whose services are managed by OpenRC, for
+// installs that write systemd units
+func requireSystemd(what string) error {
+	host, err := platform.Detect()
+	if err != nil {
+		return err
+	}
+	if host.OpenRC() {
+		return fmt.Errorf("%s needs systemd, which %s does not use", what, host)
+	}
+	return nil
+}
+
+// String renders the step as a single human readable line
+func (s Step) String() string {
+	var detail string
+	switch s.Kind {
+	case StepAptKey:
+		detail = fmt.Sprintf("%s -> %s", s.URL, s.Path)
+	case StepRepo, StepWriteFile, StepDirectory:
+		detail = s.Path
+	case StepAptPPA:
+		detail = s.PPA
+	case StepPackageInstall, StepPackageRemove:
+		detail = strings.Join(s.Packages, " ")
+	case StepRemove:
+		detail = strings.Join(s.Paths, " ")
+	case StepCreateUser, StepDeleteUser:
+		detail = s.User
+	case StepDownload:
+		detail = fmt.Sprintf("%s -> %s, verified with %s", s.URL, s.Path, s.Checksum)
+	case StepExtract:
+		detail = fmt.Sprintf("%s -> %s", s.Source, s.Path)
+	case StepSystemdUnit, StepEnableStart, StepStopDisable, StepRemoveUnit:
+		detail = strings.Join(s.Units, " ")
+	case StepCommand, StepVerify:
+		if s.Shell != "" {
+			detail = s.Shell
+		} else {
+			detail = strings.Join(s.Command, " ")
+		}
+	}
+
+	line := fmt.Sprintf("%-15s %s", s.Kind, s.Description)
+	if detail != "" {
+		line += " (" + detail + ")"
+	}
+	if s.Optional {
+		line += " [optional]"
+	}
+	return line
+}
+
+// withAfterUndo adds fn to the rollback of the step, e.g. to restart a
+// service once the file the step wrote was restored
+func (s Step) withAfterUndo(fn func() error) Step {
+	s.afterUndo = fn
+	return s
+}
+
+// optional marks a step whose failure should not abort the install
+func (s Step) optional() Step {
+	s.Optional = true
+	return s
+}
+
+func aptKey(description, url, keyring string) Step {
+	return Step{Kind: StepAptKey, Description: description, URL: url, Path: keyring, Dearmor: true}
+}
+
+// aptKeyFile stores a key as downloaded, for repositories that accept ASCII-armored keys
+func aptKeyFile(description, url, keyring string) Step {
+	return Step{Kind: StepAptKey, Description: description, URL: url, Path: keyring}
+}
+
+// repoFile writes an apt source list or yum .repo file
+func repoFile(description, path, content string) Step {
+	return Step{Kind: StepRepo, Description: description, Path: path, Content: content}
+}
+
+func aptPPA(description, ppa string) Step {
+	return Step{Kind: StepAptPPA, Description: description, PPA: ppa}
+}
+
+func packageUpdate() Step {
+	return Step{Kind: StepPackageUpdate, Description: "Updating package lists"}
+}
+
+func packageInstall(description string, packages ...string) Step {
+	return Step{Kind: StepPackageInstall, Description: description, Packages: packages}
+}
+
+func createUser(user string) Step {
+	return Step{Kind: StepCreateUser, Description: fmt.Sprintf("Creating %s user", user), User: user}
+}
+
+func directory(description, path, owner string) Step {
+	return Step{Kind: StepDirectory, Description: description, Path: path, Owner: owner}
+}
+
+// download fetches url and verifies it against the digest checksum returns;
+// from describes where that digest comes from
+func download(description, url, path, from string, checksum func() (string, error)) Step {
+	return Step{Kind: StepDownload, Description: description, URL: url, Path: path, Checksum: from, checksum: checksum}
+}
+
+func extract(description, archive, dest string, strip int) Step {
+	return Step{Kind: StepExtract, Description: description, Source: archive, Path: dest, Strip: strip}
+}
+
+func writeFile(description, path, content string, mode os.FileMode) Step {
+	return Step{Kind: StepWriteFile, Description: description, Path: path, Content: content, Mode: mode}
+}
+
+func systemdUnit(name, content string) Step {
+	return Step{Kind: StepSystemdUnit, Description: "Creating systemd service", Units: []string{name}, Content: content}
+}
+
+func enableStart(units ...string) Step {
+	return Step{Kind: StepEnableStart, Description: "Enabling and starting service", Units: units}
+}
+
+func command(description, name string, args ...string) Step {
+	return Step{Kind: StepCommand, Description: description, Command: append([]string{name}, args...)}
+}
+
+func shellCommand(description, cmd string) Step {
+	return Step{Kind: StepCommand, Description: description, Shell: cmd}
+}
+
+func verify(description, name string, args ...string) Step {
+	return Step{Kind: StepVerify, Description: description, Command: append([]string{name}, args...)}
+}
+
+// action wraps Go code that cannot be expressed as a plain command
+func action(description string, fn func() error) Step {
+	return Step{Kind: StepCommand, Description: description, run: fn}
+}
+
+func stopDisable(units ...string) Step {
+	return Step{Kind: StepStopDisable, Description: "Stopping and disabling service", Units: units}
+}
+
+func removeUnit(units ...string) Step {
+	return Step{Kind: StepRemoveUnit, Description: "Removing systemd service", Units: units}
+}
+
+func packageRemove(description string, purge bool, packages ...string) Step {
+	return Step{Kind: StepPackageRemove, Description: description, Purge: purge, Packages: packages}
+}
+
+func removePaths(description string, paths ...string) Step {
+	return Step{Kind: StepRemove, Description: description, Paths: paths}
+}
+
+func deleteUser(user string) Step {
+	return Step{Kind: StepDeleteUser, Description: fmt.Sprintf("Deleting %s user", user), User: user}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Port is a TCP port a service listens on. Name is also the setting that
+// changes it, from `install --port name=value` or a stack file.
+type Port struct {
+	Name   string
+	Number int
+}
+
+// portRegistry lists the TCP ports each service listens on by default
+var portRegistry = map[string][]Port{
+	"nginx":             {{"port", 80}},
+	"caddy":             {{"port", 80}},
+	"postgresql":        {{"port", 5432}},
+	"mongodb":           {{"port", 27017}},
+	"redis":             {{"port", 6379}},
+	"elasticsearch":     {{"port", 9200}, {"transport_port", 9300}},
+	"mysql":             {{"port", 3306}},
+	"clickhouse":        {{"port", 8123}, {"native_port", 9000}, {"metrics_port", 9363}},
+	"kafka":             {{"port", 9092}, {"controller_port", 9093}},
+	"rabbitmq":          {{"port", 5672}, {"management_port", 15672}, {"prometheus_port", 15692}},
+	"prometheus":        {{"port", 9090}},
+	"grafana":           {{"port", 3000}},
+	"alertmanager":      {{"port", 9094}},
+	"rustfs":            {{"port", 9000}, {"console_port", 9001}},
+	"seaweedfs":         {{"master_port", 9333}, {"volume_port", 8080}, {"filer_port", 8888}, {"s3_port", 8333}, {"metrics_port", 9327}},
+	"mongodb_exporter":  {{"port", 9216}},
+	"nginx_exporter":    {{"port", 9113}},
+	"node_exporter":     {{"port", 9100}},
+	"postgres_exporter": {{"port", 9187}},
+	"redis_exporter":    {{"port", 9121}},
+}
+
+// registeredPort returns the port of a service with the given name
+func registeredPort(serviceName, name string) (Port, bool) {
+	for _, port := range portRegistry[serviceName] {
+		if port.Name == name {
+			return port, true
+		}
+	}
+	return Port{}, false
+}
+
+// portOwner returns the installed service, other than the ones excluded,
+// that listens on number
+func portOwner(number int, exclude ...string) (string, string, bool) {
+	for _, serviceName := range slices.Sorted(maps.Keys(portRegistry)) {
+		if slices.Contains(exclude, serviceName) || !IsServiceInstalled(serviceName) {
+			continue
+		}
+		for _, port := range installedPorts(serviceName) {
+			if port.Number == number {
+				return serviceName, port.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// parsePort validates a port number given as a setting
+func parsePort(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 || number > 65535 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return number, nil
+}
+
+// SetPort overrides one of a service's ports for this run. The plan writes
+// the port into the service's configuration or systemd unit.
+func SetPort(serviceName, name, value string) error {
+	if _, ok := registeredPort(serviceName, name); !ok {
+		var names []string
+		for _, port := range portRegistry[serviceName] {
+			names = append(names, port.Name)
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("%s does not listen on any port", serviceName)
+		}
+		return fmt.Errorf("%s has no port named %s, its ports are %s", serviceName, name, strings.Join(names, ", "))
+	}
+	if _, err := parsePort(value); err != nil {
+		return fmt.Errorf("%s %s: %w", serviceName, name, err)
+	}
+
+	SetSetting(serviceName, name, value)
+	return nil
+}
+
+// servicePort returns the port a plan listens on, the registry default
+// unless it was changed with a setting
+func servicePort(serviceName, name string) (int, error) {
+	port, ok := registeredPort(serviceName, name)
+	if !ok {
+		return 0, fmt.Errorf("%s has no port named %s", serviceName, name)
+	}
+	number, err := parsePort(setting(serviceName, name, strconv.Itoa(port.Number)))
+	if err != nil {
+		return 0, fmt.Errorf("%s %s: %w", serviceName, name, err)
+	}
+	return number, nil
+}
+
+// servicePorts returns the ports a service will listen on with this run's
+// settings applied. Unlike servicePort it does not mark the settings as
+// used, so the preflight checks can look at them before the plan is built.
+func servicePorts(serviceName string) []Port {
+	ports := slices.Clone(portRegistry[serviceName])
+	for i, port := range ports {
+		if number, err := parsePort(installOptions[serviceName].Settings[port.Name]); err == nil {
+			ports[i].Number = number
+		}
+	}
+	return ports
+}
+
+// portConflict is a port claimed by two services installed together
+type portConflict struct {
+	first, second string
+	port          Port // the port as the second service names it
+}
+
+func (c portConflict) String() string {
+	return fmt.Sprintf("%s and %s both use port %d, change one with --port %s.%s=<port>",
+		c.first, c.second, c.port.Number, c.second, c.port.Name)
+}
+
+// portConflicts reports ports claimed by more than one of the services
+// being installed together, such as ClickHouse and RustFS both on 9000
+func portConflicts(serviceNames []string) []portConflict {
+	owners := make(map[int]string)
+	var conflicts []portConflict
+	for _, name := range serviceNames {
+		for _, port := range servicePorts(name) {
+			if owner, taken := owners[port.Number]; taken && owner != name {
+				conflicts = append(conflicts, portConflict{first: owner, second: name, port: port})
+				continue
+			}
+			owners[port.Number] = name
+		}
+	}
+	return conflicts
+}
@@ -1,26 +1,20 @@
 package services
 
-import (
-	"fmt"
-	"github.com/amirkh8006/bootup-cli/internal/utils"
-)
-
-func InstallPostgreSQL() error {
-	utils.PrintInfo("Installing PostgreSQL locally...")
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "postgresql", "postgresql-contrib"); err != nil {
-		return fmt.Errorf("failed to install PostgreSQL: %w", err)
-	}
-
-	utils.PrintInfo("Enabling PostgreSQL service...")
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "postgresql"); err != nil {
-		return fmt.Errorf("failed to enable PostgreSQL service: %w", err)
-	}
-
-	utils.PrintInfo("Starting PostgreSQL service...")
-	if err := utils.RunCommand("sudo", "systemctl", "start", "postgresql"); err != nil {
-		return fmt.Errorf("failed to start PostgreSQL service: %w", err)
+// PostgreSQLPlan builds the install plan for PostgreSQL
+func PostgreSQLPlan() (*Plan, error) {
+	plan, err := packagePlan("postgresql", "PostgreSQL")
+	if err != nil {
+		return nil, err
 	}
+	plan.Add(credentialSteps("postgresql", serviceCredentials("postgresql"))...)
+	plan.Success = "PostgreSQL installed and started successfully!"
+	plan.Note(credentialsNote("postgresql"))
+	return plan, nil
+}
 
-	utils.PrintSuccess("PostgreSQL installed and started successfully!")
-	return nil
+// PostgreSQLUninstallPlan builds the removal plan for PostgreSQL
+func PostgreSQLUninstallPlan(purge bool) (*Plan, error) {
+	return packageUninstallPlan("postgresql", uninstallSpec{
+		dataDirs: []string{"/var/lib/postgresql", "/etc/postgresql", "/var/lib/pgsql", "/var/lib/postgres"},
+	}, purge)
 }
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// CheckStatus is the outcome of a preflight check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn" // the install works but something needs attention
+	CheckFail CheckStatus = "fail" // the install would fail
+)
+
+// Check is the result of a single preflight check
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+}
+
+const (
+	// defaultDiskMB is the free space required when a service sets no DiskMB
+	defaultDiskMB = 1024
+
+	// diskCheckPath is where services keep their data
+	diskCheckPath = "/var/lib"
+
+	networkTimeout = 5 * time.Second
+)
+
+// preflightEnabled controls whether installs run the preflight checks first
+var preflightEnabled = true
+
+// SetPreflight enables or disables the preflight checks run before installs
+func SetPreflight(enabled bool) {
+	preflightEnabled = enabled
+}
+
+// Preflight checks whether a service can be installed on this host without
+// changing anything: the distribution, sudo, network access to the hosts it
+// downloads from, free ports and disk space, prerequisites and conflicting
+// packages
+func Preflight(serviceName string) ([]Check, error) {
+	service, exists := serviceRegistry[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("service %s is not supported", serviceName)
+	}
+
+	checks := []Check{checkPlatform(serviceName), checkSudo()}
+
+	installed := IsServiceInstalled(serviceName)
+	if installed {
+		checks = append(checks, Check{"installed", CheckWarn, fmt.Sprintf("%s is already installed", serviceName)})
+	}
+
+	for _, dep := range service.Requires {
+		if IsServiceInstalled(dep) {
+			checks = append(checks, Check{"requires " + dep, CheckPass, dep + " is installed"})
+		} else {
+			checks = append(checks, Check{"requires " + dep, CheckWarn, dep + " is not installed and will be installed first"})
+		}
+	}
+
+	for _, host := range serviceHosts(serviceName) {
+		checks = append(checks, checkNetwork(host))
+	}
+
+	// An installed service holds its own ports
+	if !installed && len(portRegistry[serviceName]) > 0 {
+		checks = append(checks, checkPorts(servicePorts(serviceName))...)
+	}
+
+	diskMB := service.DiskMB
+	if diskMB == 0 {
+		diskMB = defaultDiskMB
+	}
+	checks = append(checks, checkDisk(diskCheckPath, diskMB))
+	checks = append(checks, checkConflicts(serviceName)...)
+
+	if service.Preflight != nil {
+		checks = append(checks, service.Preflight()...)
+	}
+	return checks, nil
+}
+
+// preflightServices runs the preflight checks of the services about to be
+// installed and prints the ones that did not pass. Failures abort the
+// install unless this is a dry run.
+func preflightServices(serviceNames []string) error {
+	if !preflightEnabled {
+		return nil
+	}
+
+	var failed []string
+	for _, conflict := range portConflicts(serviceNames) {
+		utils.PrintError(conflict.String())
+		failed = append(failed, conflict.second)
+	}
+	for _, name := range serviceNames {
+		checks, err := Preflight(name)
+		if err != nil {
+			return err
+		}
+		for _, check := range checks {
+			switch check.Status {
+			case CheckWarn:
+				utils.PrintWarning(fmt.Sprintf("%s: %s", name, check.Detail))
+			case CheckFail:
+				utils.PrintError(fmt.Sprintf("%s: %s", name, check.Detail))
+				if !slices.Contains(failed, name) {
+					failed = append(failed, name)
+				}
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	if utils.IsDryRun() {
+		utils.PrintWarning("Continuing the dry run although preflight checks failed")
+		return nil
+	}
+	return fmt.Errorf("preflight checks failed for %s, see bootup preflight %s or pass --skip-preflight",
+		strings.Join(failed, ", "), failed[0])
+}
+
+// checkPlatform reports whether the service is published for this
+// distribution release and architecture
+func checkPlatform(serviceName string) Check {
+	if err := CheckSupported(serviceName); err != nil {
+		return Check{"platform", CheckFail, err.Error()}
+	}
+	host, err := platform.Detect()
+	if err != nil {
+		return Check{"platform", CheckFail, err.Error()}
+	}
+	arch, err := platform.DetectArch()
+	if err != nil {
+		return Check{"platform", CheckFail, err.Error()}
+	}
+	return Check{"platform", CheckPass, fmt.Sprintf("%s on %s", host, arch)}
+}
+
+// checkSudo reports whether the install can run commands as root
+func checkSudo() Check {
+	if os.Geteuid() == 0 {
+		return Check{"sudo", CheckPass, "running as root"}
+	}
+	if !isCommandAvailable("sudo") {
+		return Check{"sudo", CheckFail, "sudo is not installed and bootup is not running as root"}
+	}
+	if utils.CheckCommand("sudo", "-n", "true") {
+		return Check{"sudo", CheckPass, "sudo works without a password"}
+	}
+	return Check{"sudo", CheckWarn, "sudo will ask for a password"}
+}
+
+// checkNetwork reports whether host accepts HTTPS connections
+func checkNetwork(host string) Check {
+	name := "network " + host
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, "443"), networkTimeout)
+	if err != nil {
+		return Check{name, CheckFail, fmt.Sprintf("cannot reach %s: %v", host, err)}
+	}
+	conn.Close()
+	return Check{name, CheckPass, host + " is reachable"}
+}
+
+// checkPorts reports whether nothing listens on the ports yet
+func checkPorts(ports []Port) []Check {
+	listening, err := platform.ListeningPorts()
+	if err != nil {
+		return []Check{{"ports", CheckWarn, fmt.Sprintf("failed to check listening ports: %v", err)}}
+	}
+
+	var checks []Check
+	for _, port := range ports {
+		name := "port " + strconv.Itoa(port.Number)
+		if listening[port.Number] {
+			checks = append(checks, Check{name, CheckFail,
+				fmt.Sprintf("port %d is already in use, choose another with --port %s=<port>", port.Number, port.Name)})
+		} else {
+			checks = append(checks, Check{name, CheckPass, fmt.Sprintf("port %d is free", port.Number)})
+		}
+	}
+	return checks
+}
+
+// checkDisk reports whether the filesystem holding path has at least
+// requiredMB free
+func checkDisk(path string, requiredMB int) Check {
+	// Check the closest directory that exists, e.g. / on a fresh container
+	for {
+		if _, err := os.Stat(path); err == nil || path == "/" {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return Check{"disk", CheckWarn, fmt.Sprintf("failed to check free space in %s: %v", path, err)}
+	}
+	freeMB := int(stat.Bavail * uint64(stat.Bsize) / (1024 * 1024))
+	if freeMB < requiredMB {
+		return Check{"disk", CheckFail, fmt.Sprintf("%s has %d MB free, %d MB needed", path, freeMB, requiredMB)}
+	}
+	return Check{"disk", CheckPass, fmt.Sprintf("%s has %d MB free", path, freeMB)}
+}
+
+// checkConflicts lists the installed packages the install removes first
+func checkConflicts(serviceName string) []Check {
+	distro, _, err := distroFor(serviceName)
+	if err != nil || len(distro.Conflicts) == 0 {
+		return nil
+	}
+	pm, err := packageManager()
+	if err != nil {
+		return nil
+	}
+
+	var checks []Check
+	for _, pkg := range distro.Conflicts {
+		if pm.Installed(pkg) {
+			checks = append(checks, Check{"conflict " + pkg, CheckWarn, fmt.Sprintf("%s is installed and will be removed", pkg)})
+		}
+	}
+	if len(checks) == 0 {
+		checks = append(checks, Check{"conflicts", CheckPass, "no conflicting packages are installed"})
+	}
+	return checks
+}
+
+// repoURL finds the URLs in repository files and keys
+var repoURL = regexp.MustCompile(`https://[^\s'"]+`)
+
+// serviceHosts returns the hosts an install downloads from: the release
+// download, the third-party repositories and any hosts the service lists.
+// Distribution mirrors are not checked.
+func serviceHosts(serviceName string) []string {
+	var urls []string
+	if release, ok := releaseRegistry[serviceName]; ok {
+		urls = append(urls, release.URL)
+	}
+	if _, ok := packageRegistry[serviceName]; ok {
+		if distro, _, err := distroFor(serviceName); err == nil {
+			for _, repo := range distro.Repos {
+				urls = append(urls, repoURL.FindAllString(repo.Content, -1)...)
+				urls = append(urls, repo.Key)
+			}
+		}
+	}
+
+	var hosts []string
+	for _, raw := range urls {
+		if u, err := url.Parse(raw); err == nil && u.Hostname() != "" && !slices.Contains(hosts, u.Hostname()) {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	for _, host := range serviceRegistry[serviceName].Hosts {
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
@@ -2,83 +2,184 @@ package services
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
-func InstallPrometheus() error {
-	utils.PrintInfo("Installing Prometheus...")
-
-	// Define version and directories
-	prometheusVersion := "3.0.1"
-	prometheusUser := "prometheus"
-	prometheusDir := "/opt/prometheus"
-	prometheusDataDir := "/var/lib/prometheus"
-	prometheusConfigFile := "/etc/prometheus/prometheus.yml"
-	prometheusTarball := "/tmp/prometheus.tar.gz"
-
-	// Create prometheus user if not exists
-	utils.PrintInfo("Creating prometheus user...")
-	checkUserCmd := fmt.Sprintf("id %s", prometheusUser)
-	if err := utils.RunCommandShell(checkUserCmd); err != nil {
-		// User doesn't exist, create it
-		createUserCmd := fmt.Sprintf("sudo useradd --no-create-home --shell /usr/sbin/nologin %s", prometheusUser)
-		if err := utils.RunCommandShell(createUserCmd); err != nil {
-			return fmt.Errorf("failed to create prometheus user: %w", err)
-		}
-	}
+const (
+	prometheusUser       = "prometheus"
+	prometheusDir        = "/opt/prometheus"
+	prometheusDataDir    = "/var/lib/prometheus"
+	prometheusConfigDir  = "/etc/prometheus"
+	prometheusConfigFile = "/etc/prometheus/prometheus.yml"
+	prometheusTarball    = "/tmp/prometheus.tar.gz"
+)
+
+// prometheusSettingKeys are the settings PrometheusPlan and `bootup
+// configure prometheus` understand
+var prometheusSettingKeys = []string{
+	"port", "listen_address", "external_url", "retention_time", "retention_size", "scrape_interval", "data_dir",
+}
+
+var (
+	// prometheusDuration matches Prometheus durations such as 15s, 1h30m or 30d
+	prometheusDuration = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|y))+$`)
+
+	// prometheusSize matches storage sizes such as 512MB or 50GB
+	prometheusSize = regexp.MustCompile(`^[0-9]+(B|KB|MB|GB|TB|PB|EB)$`)
+)
 
-	// Download Prometheus
-	utils.PrintInfo(fmt.Sprintf("Downloading Prometheus %s...", prometheusVersion))
-	downloadUrl := fmt.Sprintf("https://github.com/prometheus/prometheus/releases/download/v%s/prometheus-%s.linux-amd64.tar.gz", prometheusVersion, prometheusVersion)
-	downloadCmd := fmt.Sprintf("wget '%s' -O %s", downloadUrl, prometheusTarball)
-	if err := utils.RunCommandShell(downloadCmd); err != nil {
-		return fmt.Errorf("failed to download Prometheus: %w", err)
+// prometheusSettings are the server settings rendered into the Prometheus
+// unit and configuration
+type prometheusSettings struct {
+	port           int
+	listenAddress  string // --web.listen-address, host:port
+	externalURL    string
+	retentionTime  string
+	retentionSize  string
+	scrapeInterval string
+	dataDir        string
+	alertmanager   string // host:port alerts are sent to, empty without Alertmanager
+}
+
+// newPrometheusSettings validates the settings returned by get. A
+// listen_address may carry its own port, which must then agree with port
+// when that was set as well.
+func newPrometheusSettings(get func(key, fallback string) string, port int, portSet bool) (prometheusSettings, error) {
+	s := prometheusSettings{
+		port:           port,
+		externalURL:    get("external_url", ""),
+		retentionTime:  get("retention_time", ""),
+		retentionSize:  get("retention_size", ""),
+		scrapeInterval: get("scrape_interval", "15s"),
+		dataDir:        get("data_dir", prometheusDataDir),
 	}
 
-	// Create and extract to directory
-	utils.PrintInfo("Extracting Prometheus...")
-	if err := utils.RunCommandShell(fmt.Sprintf("sudo mkdir -p %s", prometheusDir)); err != nil {
-		return fmt.Errorf("failed to create prometheus directory: %w", err)
+	listen := get("listen_address", "")
+	if host, rawPort, err := net.SplitHostPort(listen); err == nil {
+		listenPort, err := parsePort(rawPort)
+		if err != nil {
+			return s, fmt.Errorf("prometheus listen_address: %w", err)
+		}
+		if portSet && listenPort != port {
+			return s, fmt.Errorf("prometheus listen_address %s does not match port %d", listen, port)
+		}
+		s.port = listenPort
+		listen = host
 	}
+	s.listenAddress = net.JoinHostPort(listen, strconv.Itoa(s.port))
 
-	extractCmd := fmt.Sprintf("sudo tar -xzf %s --strip-components=1 -C %s", prometheusTarball, prometheusDir)
-	if err := utils.RunCommandShell(extractCmd); err != nil {
-		return fmt.Errorf("failed to extract Prometheus: %w", err)
+	if s.externalURL != "" {
+		u, err := url.Parse(s.externalURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return s, fmt.Errorf("prometheus external_url %q is not an http or https URL", s.externalURL)
+		}
+	}
+	for key, value := range map[string]string{"retention_time": s.retentionTime, "scrape_interval": s.scrapeInterval} {
+		if value != "" && !prometheusDuration.MatchString(value) {
+			return s, fmt.Errorf("prometheus %s %q is not a duration such as 15s or 30d", key, value)
+		}
 	}
+	if s.retentionSize != "" && !prometheusSize.MatchString(s.retentionSize) {
+		return s, fmt.Errorf("prometheus retention_size %q is not a size such as 512MB or 50GB", s.retentionSize)
+	}
+	if !filepath.IsAbs(s.dataDir) {
+		return s, fmt.Errorf("prometheus data_dir %q is not an absolute path", s.dataDir)
+	}
+	return s, nil
+}
 
-	// Create data and config directories
-	utils.PrintInfo("Creating configuration directories...")
-	if err := utils.RunCommandShell(fmt.Sprintf("sudo mkdir -p %s /etc/prometheus", prometheusDataDir)); err != nil {
-		return fmt.Errorf("failed to create prometheus data directory: %w", err)
+// config returns the settings as recorded in the state file
+func (s prometheusSettings) config() map[string]string {
+	config := map[string]string{
+		"config_file":     prometheusConfigFile,
+		"data_dir":        s.dataDir,
+		"listen_address":  s.listenAddress,
+		"port":            strconv.Itoa(s.port),
+		"scrape_interval": s.scrapeInterval,
+		"user":            prometheusUser,
+	}
+	for key, value := range map[string]string{
+		"external_url":   s.externalURL,
+		"retention_time": s.retentionTime,
+		"retention_size": s.retentionSize,
+	} {
+		if value != "" {
+			config[key] = value
+		}
 	}
+	return config
+}
 
-	// Create default config
-	utils.PrintInfo("Creating default configuration...")
-	configContent := `global:
-  scrape_interval: 15s
-  evaluation_interval: 15s
+// localAddress returns the address Prometheus can be reached at from this
+// host, which is loopback unless it listens on a single address
+func (s prometheusSettings) localAddress() string {
+	host, _, _ := net.SplitHostPort(s.listenAddress)
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(s.port))
+}
 
+// prometheusConfig renders prometheus.yml
+func prometheusConfig(s prometheusSettings) string {
+	metricsPath := ""
+	if prefix := prometheusRoutePrefix(s.externalURL); prefix != "" {
+		metricsPath = fmt.Sprintf("    metrics_path: '%s/metrics'\n", prefix)
+	}
+	alerting := ""
+	if s.alertmanager != "" {
+		alerting = fmt.Sprintf(`
+alerting:
+  alertmanagers:
+    - static_configs:
+        - targets: ['%s']
+`, s.alertmanager)
+	}
+	return fmt.Sprintf(`global:
+  scrape_interval: %s
+  evaluation_interval: %s
+%s
 scrape_configs:
   - job_name: 'prometheus'
-    static_configs:
-      - targets: ['localhost:9090']`
+%s    static_configs:
+      - targets: ['%s']
 
-	createConfigCmd := fmt.Sprintf("sudo tee %s > /dev/null <<'EOL'\n%s\nEOL", prometheusConfigFile, configContent)
-	if err := utils.RunCommandShell(createConfigCmd); err != nil {
-		return fmt.Errorf("failed to create prometheus config: %w", err)
-	}
+  # Services installed by bootup drop a target file into targets.d,
+  # labelled with the service as the job
+  - job_name: 'bootup'
+    file_sd_configs:
+      - files: ['%s/*.yml']
+`, s.scrapeInterval, s.scrapeInterval, alerting, metricsPath, s.localAddress(), prometheusTargetsDir)
+}
 
-	// Fix permissions
-	utils.PrintInfo("Setting permissions...")
-	chownCmd := fmt.Sprintf("sudo chown -R %s:%s %s %s /etc/prometheus", prometheusUser, prometheusUser, prometheusDir, prometheusDataDir)
-	if err := utils.RunCommandShell(chownCmd); err != nil {
-		return fmt.Errorf("failed to set permissions: %w", err)
+// prometheusUnit renders the systemd unit
+func prometheusUnit(s prometheusSettings) string {
+	flags := []string{
+		"--config.file=" + prometheusConfigFile,
+		"--storage.tsdb.path=" + s.dataDir,
+		"--web.listen-address=" + s.listenAddress,
+	}
+	if s.externalURL != "" {
+		flags = append(flags, "--web.external-url="+s.externalURL)
+	}
+	if s.retentionTime != "" {
+		flags = append(flags, "--storage.tsdb.retention.time="+s.retentionTime)
+	}
+	if s.retentionSize != "" {
+		flags = append(flags, "--storage.tsdb.retention.size="+s.retentionSize)
 	}
 
-	// Create systemd service
-	utils.PrintInfo("Creating systemd service...")
-	serviceContent := fmt.Sprintf(`[Unit]
+	return fmt.Sprintf(`[Unit]
 Description=Prometheus Monitoring
 Wants=network-online.target
 After=network-online.target
@@ -88,40 +189,201 @@ User=%s
 Group=%s
 Type=simple
 ExecStart=%s/prometheus \
-  --config.file=%s \
-  --storage.tsdb.path=%s \
-  --web.listen-address=:9090 \
-  --web.external-url=https://prometheus.khanetalaa.ir/
+  %s
+ExecReload=/bin/kill -HUP $MAINPID
 
 Restart=always
 
 [Install]
-WantedBy=multi-user.target`, prometheusUser, prometheusUser, prometheusDir, prometheusConfigFile, prometheusDataDir)
+WantedBy=multi-user.target
+`, prometheusUser, prometheusUser, prometheusDir, strings.Join(flags, " \\\n  "))
+}
+
+// prometheusHealthy checks /-/healthy, which moves under the path of the
+// external URL when one is set
+func prometheusHealthy(s prometheusSettings) error {
+	return httpCheck(s.localAddress(), prometheusRoutePrefix(s.externalURL)+"/-/healthy")
+}
+
+// prometheusProbe is the health probe of an installed Prometheus
+func prometheusProbe(port int) error {
+	record, _ := GetInstallRecord("prometheus")
+	return httpProbe(prometheusRoutePrefix(record.Config["external_url"]) + "/-/healthy")(port)
+}
 
-	createServiceCmd := fmt.Sprintf("sudo tee /etc/systemd/system/prometheus.service > /dev/null <<'EOL'\n%s\nEOL", serviceContent)
-	if err := utils.RunCommandShell(createServiceCmd); err != nil {
-		return fmt.Errorf("failed to create systemd service: %w", err)
+// prometheusRoutePrefix returns the path Prometheus serves its routes under
+func prometheusRoutePrefix(externalURL string) string {
+	u, err := url.Parse(externalURL)
+	if err != nil {
+		return ""
 	}
+	return strings.TrimSuffix(u.Path, "/")
+}
 
-	// Enable and start service
-	utils.PrintInfo("Starting Prometheus service...")
-	if err := utils.RunCommand("sudo", "systemctl", "daemon-reload"); err != nil {
-		return fmt.Errorf("failed to reload systemd: %w", err)
+// PrometheusPlan builds the install plan for Prometheus
+func PrometheusPlan() (*Plan, error) {
+	release := selectRelease("prometheus")
+	owner := prometheusUser + ":" + prometheusUser
+	port, err := servicePort("prometheus", "port")
+	if err != nil {
+		return nil, err
+	}
+	_, portSet := installOptions["prometheus"].Settings["port"]
+	settings, err := newPrometheusSettings(func(key, fallback string) string {
+		return setting("prometheus", key, fallback)
+	}, port, portSet)
+	if err != nil {
+		return nil, err
+	}
+	settings.alertmanager = installedAlertmanagerAddress()
+
+	plan := NewPlan("prometheus")
+	plan.Version = release.Version
+	plan.Config = settings.config()
+	plan.Add(
+		createUser(prometheusUser),
+		release.download(fmt.Sprintf("Downloading Prometheus %s", release.Version), prometheusTarball),
+		extract("Extracting Prometheus", prometheusTarball, prometheusDir, 1),
+		directory("Creating data directory", settings.dataDir, owner),
+		directory("Creating configuration directory", prometheusConfigDir, ""),
+		directory("Creating targets directory", prometheusTargetsDir, ""),
+		writeFile("Creating default configuration", prometheusConfigFile, prometheusConfig(settings), 0644),
+		command("Setting permissions", "sudo", "chown", "-R", owner, prometheusDir, prometheusConfigDir),
+		systemdUnit("prometheus", prometheusUnit(settings)),
+		enableStart("prometheus"),
+		command("Cleaning up downloaded archive", "rm", "-f", prometheusTarball).optional(),
+	)
+	if IsServiceInstalled("grafana") {
+		plan.Add(grafanaProvisioningSteps(settings)...)
+	}
+	plan.Success = "Prometheus installed and running!"
+	plan.Note(fmt.Sprintf("Prometheus is accessible at http://localhost:%d", settings.port))
+	if settings.externalURL != "" {
+		plan.Note("External URL: " + settings.externalURL)
+	}
+	if IsServiceInstalled("grafana") {
+		plan.Note(grafanaDashboardNote(grafanaInstalledPort()))
+	}
+	return plan, nil
+}
+
+// PrometheusConfigurePlan rewrites the configuration and unit of an
+// installed Prometheus with the given settings changed. The new
+// configuration is checked with promtool before anything is replaced, and
+// if Prometheus does not come back healthy the previous files are restored
+// and it is restarted with them.
+func PrometheusConfigurePlan(changes map[string]string) (*Plan, error) {
+	for key := range changes {
+		if !slices.Contains(prometheusSettingKeys, key) {
+			return nil, fmt.Errorf("prometheus has no setting %s, its settings are %s", key, strings.Join(prometheusSettingKeys, ", "))
+		}
 	}
+	record, ok := GetInstallRecord("prometheus")
+	if !ok {
+		return nil, fmt.Errorf("prometheus was not installed by bootup, so its settings are unknown")
+	}
+	changes = maps.Clone(changes)
 
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "prometheus"); err != nil {
-		return fmt.Errorf("failed to enable prometheus service: %w", err)
+	// A new port replaces the one in the recorded listen address
+	if _, ok := changes["port"]; ok {
+		if _, ok := changes["listen_address"]; !ok {
+			host, _, _ := net.SplitHostPort(record.Config["listen_address"])
+			changes["listen_address"] = host
+		}
+	}
+	get := func(key, fallback string) string {
+		if value, ok := changes[key]; ok {
+			// An empty value resets the setting to its default
+			if value == "" {
+				return fallback
+			}
+			return value
+		}
+		if value := record.Config[key]; value != "" {
+			return value
+		}
+		return fallback
+	}
+	port, err := parsePort(get("port", "9090"))
+	if err != nil {
+		return nil, fmt.Errorf("prometheus port: %w", err)
 	}
+	_, portSet := changes["port"]
+	settings, err := newPrometheusSettings(get, port, portSet)
+	if err != nil {
+		return nil, err
+	}
+	settings.alertmanager = installedAlertmanagerAddress()
+
+	owner := prometheusUser + ":" + prometheusUser
+	candidate := filepath.Join(os.TempDir(), "bootup-prometheus.yml")
+	config := prometheusConfig(settings)
 
-	if err := utils.RunCommand("sudo", "systemctl", "start", "prometheus"); err != nil {
-		return fmt.Errorf("failed to start prometheus service: %w", err)
+	plan := NewPlan("prometheus")
+	plan.Config = settings.config()
+	plan.Add(
+		writeFile("Writing the new configuration for checking", candidate, config, 0644),
+		verify("Checking the new configuration", filepath.Join(prometheusDir, "promtool"), "check", "config", "--syntax-only", candidate),
+		command("Removing the checked configuration", "rm", "-f", candidate).optional(),
+	)
+	if settings.dataDir != record.Config["data_dir"] {
+		plan.Add(directory("Creating data directory", settings.dataDir, owner))
 	}
+	plan.Add(
+		// Rolling this back runs last, once the unit was restored as well
+		writeFile("Writing configuration", prometheusConfigFile, config, 0644).withAfterUndo(func() error {
+			utils.PrintInfo("Restarting Prometheus with the previous configuration...")
+			return restartService("prometheus").Apply()
+		}),
+		systemdUnit("prometheus", prometheusUnit(settings)),
+		restartService("prometheus"),
+		waitHealthy("Waiting for Prometheus to become healthy", func() error {
+			return prometheusHealthy(settings)
+		}),
+	)
+	// Point the Grafana datasource at the new address
+	previous := installedPrometheusSettings()
+	if IsServiceInstalled("grafana") && (settings.url() != previous.url() || settings.scrapeInterval != previous.scrapeInterval) {
+		plan.Add(
+			writeFile("Updating Grafana Prometheus datasource", grafanaDatasourceFile,
+				grafanaDatasource(settings.url(), settings.scrapeInterval), 0644),
+			restartService(grafanaUnit()).optional(),
+		)
+	}
+	plan.Success = "Prometheus reconfigured and running!"
+	if settings.dataDir != record.Config["data_dir"] && record.Config["data_dir"] != "" {
+		plan.Note(fmt.Sprintf("Existing data was left in %s", record.Config["data_dir"]))
+	}
+	return plan, nil
+}
 
-	// Clean up downloaded tarball
-	_ = utils.RunCommandShell(fmt.Sprintf("rm -f %s", prometheusTarball))
+// PrometheusUninstallPlan builds the removal plan for Prometheus
+func PrometheusUninstallPlan(purge bool) (*Plan, error) {
+	dataDir := prometheusDataDir
+	if record, ok := GetInstallRecord("prometheus"); ok && record.Config["data_dir"] != "" {
+		dataDir = record.Config["data_dir"]
+	}
 
-	utils.PrintSuccess("Prometheus installed and running!")
-	utils.PrintInfo("Prometheus is accessible at http://localhost:9090")
+	spec := uninstallSpec{
+		units:     []string{"prometheus"},
+		unitFiles: []string{"prometheus"},
+		files:     []string{prometheusDir, grafanaDatasourceFile},
+		dataDirs:  []string{dataDir, prometheusConfigDir},
+	}
+	// Alertmanager runs as the same user
+	if !IsServiceInstalled("alertmanager") {
+		spec.users = []string{prometheusUser}
+	}
+	return uninstallPlan("prometheus", spec, purge), nil
+}
 
-	return nil
+// PrometheusUpgradePlan replaces the Prometheus binaries with another release
+func PrometheusUpgradePlan(version string) (*Plan, error) {
+	return tarballUpgradePlan(tarballUpgrade{
+		service: "prometheus",
+		title:   "Prometheus",
+		unit:    "prometheus",
+		dir:     prometheusDir,
+		owner:   prometheusUser + ":" + prometheusUser,
+	}, version), nil
 }
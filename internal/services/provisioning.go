@@ -0,0 +1,175 @@
+package services
+
+import (
+	"embed"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// grafanaProvisioningDir is read by Grafana at startup for datasources
+	// and dashboard providers
+	grafanaProvisioningDir = "/etc/grafana/provisioning"
+
+	// grafanaDatasourceUID is referenced by every embedded dashboard
+	grafanaDatasourceUID = "bootup-prometheus"
+)
+
+var (
+	grafanaDatasourceFile    = filepath.Join(grafanaProvisioningDir, "datasources", "bootup-prometheus.yaml")
+	grafanaDashboardProvider = filepath.Join(grafanaProvisioningDir, "dashboards", "bootup.yaml")
+
+	// grafanaDashboardsDir holds the dashboard JSON files. The provider
+	// rescans it, so dashboards added or removed later show up without a
+	// restart.
+	grafanaDashboardsDir = filepath.Join(grafanaProvisioningDir, "dashboards", "bootup")
+)
+
+// dashboards are the Grafana dashboards shipped for the exporters, one
+// <service>.json per exporter
+//
+//go:embed dashboards/*.json
+var dashboards embed.FS
+
+// grafanaDashboard returns the embedded dashboard of a service
+func grafanaDashboard(serviceName string) (string, bool) {
+	data, err := dashboards.ReadFile("dashboards/" + serviceName + ".json")
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// grafanaDashboardFile is where the dashboard of a service is provisioned
+func grafanaDashboardFile(serviceName string) string {
+	return filepath.Join(grafanaDashboardsDir, serviceName+".json")
+}
+
+// grafanaDatasource renders the Prometheus datasource, with the uid the
+// embedded dashboards query
+func grafanaDatasource(prometheusURL, scrapeInterval string) string {
+	return fmt.Sprintf(`# Written by bootup, removed when Grafana or Prometheus is uninstalled
+apiVersion: 1
+
+datasources:
+  - name: Prometheus
+    uid: %s
+    type: prometheus
+    access: proxy
+    url: %s
+    isDefault: true
+    jsonData:
+      timeInterval: %s
+`, grafanaDatasourceUID, prometheusURL, scrapeInterval)
+}
+
+// grafanaDashboardProviderConfig points Grafana at grafanaDashboardsDir
+func grafanaDashboardProviderConfig() string {
+	return fmt.Sprintf(`# Written by bootup, removed when Grafana is uninstalled
+apiVersion: 1
+
+providers:
+  - name: bootup
+    folder: bootup
+    type: file
+    updateIntervalSeconds: 30
+    allowUiUpdates: true
+    options:
+      path: %s
+`, grafanaDashboardsDir)
+}
+
+// url returns the URL Grafana reaches Prometheus at on this host
+func (s prometheusSettings) url() string {
+	return "http://" + s.localAddress() + prometheusRoutePrefix(s.externalURL)
+}
+
+// installedPrometheusSettings returns the address settings of an installed
+// Prometheus as recorded, or the defaults when it was not installed by
+// bootup
+func installedPrometheusSettings() prometheusSettings {
+	record, _ := GetInstallRecord("prometheus")
+	port, err := parsePort(record.Config["port"])
+	if err != nil {
+		port = 9090
+	}
+	s := prometheusSettings{
+		port:           port,
+		listenAddress:  record.Config["listen_address"],
+		externalURL:    record.Config["external_url"],
+		scrapeInterval: record.Config["scrape_interval"],
+	}
+	if s.listenAddress == "" {
+		s.listenAddress = net.JoinHostPort("", strconv.Itoa(port))
+	}
+	if s.scrapeInterval == "" {
+		s.scrapeInterval = "15s"
+	}
+	return s
+}
+
+// grafanaUnit returns the unit Grafana runs as, which differs between the
+// upstream packages and the distribution ones
+func grafanaUnit() string {
+	if units := serviceUnits("grafana"); len(units) > 0 {
+		return units[0]
+	}
+	return "grafana-server"
+}
+
+// grafanaProvisioningSteps wire Grafana up to Prometheus: the datasource,
+// the dashboard provider and the dashboards of the installed exporters.
+// Grafana reads datasources only at startup, so it is restarted.
+func grafanaProvisioningSteps(prometheus prometheusSettings) []Step {
+	steps := []Step{
+		directory("Creating Grafana datasource directory", filepath.Dir(grafanaDatasourceFile), ""),
+		writeFile("Adding Prometheus datasource to Grafana", grafanaDatasourceFile,
+			grafanaDatasource(prometheus.url(), prometheus.scrapeInterval), 0644),
+		directory("Creating Grafana dashboards directory", grafanaDashboardsDir, ""),
+		writeFile("Adding bootup dashboard provider to Grafana", grafanaDashboardProvider, grafanaDashboardProviderConfig(), 0644),
+	}
+	for _, name := range dashboardServices() {
+		if step, ok := grafanaDashboardStep(name); ok && IsServiceInstalled(name) {
+			steps = append(steps, step)
+		}
+	}
+	return append(steps, restartService(grafanaUnit()))
+}
+
+// grafanaDashboardStep adds the embedded dashboard of a service to Grafana,
+// if there is one
+func grafanaDashboardStep(serviceName string) (Step, bool) {
+	dashboard, ok := grafanaDashboard(serviceName)
+	if !ok {
+		return Step{}, false
+	}
+	return writeFile(fmt.Sprintf("Adding %s dashboard to Grafana", serviceName), grafanaDashboardFile(serviceName), dashboard, 0644), true
+}
+
+// grafanaDashboardNote tells the user where the dashboards are
+func grafanaDashboardNote(grafanaPort int) string {
+	return fmt.Sprintf("Dashboards are in the bootup folder at http://localhost:%d/dashboards?tag=bootup", grafanaPort)
+}
+
+// grafanaInstalledPort returns the port of an installed Grafana
+func grafanaInstalledPort() int {
+	for _, port := range installedPorts("grafana") {
+		if port.Name == "port" {
+			return port.Number
+		}
+	}
+	return 3000
+}
+
+// dashboardServices returns the services a dashboard is shipped for
+func dashboardServices() []string {
+	entries, _ := dashboards.ReadDir("dashboards")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	return names
+}
@@ -1,52 +1,25 @@
 package services
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
-func InstallPython() error {
-	utils.PrintInfo("Installing Python...")
-
-	// Check if running on supported OS
-	if runtime.GOOS != "linux" {
-		return fmt.Errorf("Python installation is currently only supported on Linux")
+// PythonPlan asks which Python version to install and builds its install plan
+func PythonPlan() (*Plan, error) {
+	_, host, err := distroFor("python")
+	if err != nil {
+		return nil, err
 	}
-
-	// For now, assume Ubuntu/Debian (like other services)
-	// Can be extended later for other distributions
-	return installPythonDebian()
+	return pythonDebianPlan(host.Base == "ubuntu")
 }
 
-func installPythonDebian() error {
-	utils.PrintInfo("Installing Python on Ubuntu/Debian...")
-
-	// Update package lists
-	if err := utils.RunCommand("sudo", "apt", "update"); err != nil {
-		return fmt.Errorf("failed to update package lists: %w", err)
-	}
-
-	// Add deadsnakes PPA for additional Python versions (Ubuntu only)
-	utils.PrintInfo("Adding deadsnakes PPA for more Python version options...")
-	if err := utils.RunCommand("sudo", "apt", "install", "-y", "software-properties-common"); err != nil {
-		utils.PrintWarning("Failed to install software-properties-common, continuing with default repositories")
-	} else {
-		if err := utils.RunCommand("sudo", "add-apt-repository", "-y", "ppa:deadsnakes/ppa"); err != nil {
-			utils.PrintWarning("Failed to add deadsnakes PPA, using default repositories")
-		} else {
-			// Update package lists after adding PPA
-			if err := utils.RunCommand("sudo", "apt", "update"); err != nil {
-				return fmt.Errorf("failed to update package lists after adding PPA: %w", err)
-			}
-		}
-	}
-
+// pythonDebianPlan installs Python from the Debian packages, adding the
+// deadsnakes PPA for versions the release does not ship on Ubuntu
+func pythonDebianPlan(withPPA bool) (*Plan, error) {
 	// Display available Python versions
 	versions := []string{
 		"python3.12",
@@ -56,9 +29,13 @@ func installPythonDebian() error {
 		"python3.8",
 	}
 
-	selectedVersion, err := displayAndSelectPythonVersion(versions)
-	if err != nil {
-		return fmt.Errorf("failed to select version: %w", err)
+	selectedVersion := "python" + normalizeVersion(requestedVersion("python"))
+	if selectedVersion == "python" {
+		var err error
+		selectedVersion, err = displayAndSelectPythonVersion(versions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select version: %w", err)
+		}
 	}
 
 	// Install Python and essential packages
@@ -74,31 +51,85 @@ func installPythonDebian() error {
 		"python3-setuptools",
 	}
 
-	utils.PrintInfo(fmt.Sprintf("Installing %s and essential packages...", selectedVersion))
-	args := append([]string{"apt", "install", "-y"}, packages...)
-	if err := utils.RunCommand("sudo", args...); err != nil {
-		return fmt.Errorf("failed to install Python: %w", err)
-	}
-
-	// Update alternatives to make the selected version default
-	if err := setupPythonAlternatives(selectedVersion); err != nil {
-		utils.PrintWarning("Failed to setup Python alternatives: " + err.Error())
-	}
-
-	// Install/upgrade pip
-	if err := upgradePip(selectedVersion); err != nil {
-		utils.PrintWarning("Failed to upgrade pip: " + err.Error())
-	}
+	plan := NewPlan("python")
+	plan.Version = strings.TrimPrefix(selectedVersion, "python")
+	plan.Add(packageUpdate())
+	if withPPA {
+		// Add deadsnakes PPA for additional Python versions
+		plan.Add(
+			packageInstall("Installing software-properties-common", "software-properties-common").optional(),
+			aptPPA("Adding deadsnakes PPA", "ppa:deadsnakes/ppa").optional(),
+			packageUpdate(),
+		)
+	}
+	plan.Add(
+		packageInstall(fmt.Sprintf("Installing %s and essential packages", selectedVersion), packages...),
+		// Update alternatives to make the selected version default
+		command("Setting up python3 alternative", "sudo", "update-alternatives", "--install", "/usr/bin/python3", "python3", "/usr/bin/"+selectedVersion, "1").optional(),
+		command("Setting up python alternative", "sudo", "update-alternatives", "--install", "/usr/bin/python", "python", "/usr/bin/"+selectedVersion, "1").optional(),
+		action("Upgrading pip", func() error { return upgradePip(selectedVersion) }).optional(),
+	)
 
 	// Install common Python packages
-	if err := installCommonPackages(); err != nil {
-		utils.PrintWarning("Failed to install some common packages: " + err.Error())
-	}
+	for _, pkg := range commonPythonPackages {
+		plan.Add(command(fmt.Sprintf("Installing %s", pkg), "python3", "-m", "pip", "install", pkg).optional())
+	}
+
+	plan.Add(
+		verify("Checking Python version", "python3", "--version").optional(),
+		verify("Checking pip version", "python3", "-m", "pip", "--version").optional(),
+	)
+
+	plan.Success = "Python is ready to use!"
+	plan.Note(
+		"Tips:",
+		"- Create virtual environments: python3 -m venv myenv",
+		"- Activate virtual environment: source myenv/bin/activate",
+		"- Install packages: pip install package_name",
+		"- Use pipenv for project management: pipenv install",
+	)
+	return plan, nil
+}
 
-	utils.PrintSuccess("Python installation completed successfully!")
+var commonPythonPackages = []string{
+	"virtualenv",
+	"wheel",
+	"setuptools",
+	"requests",
+	"numpy",
+	"pandas",
+	"matplotlib",
+	"pytest",
+	"black",
+	"flake8",
+	"pipenv",
+}
 
-	// Display installation info
-	return displayPythonInfo(selectedVersion)
+// PythonUninstallPlan removes the development packages and deadsnakes PPA added
+// for the active python3. The interpreter itself is kept because the OS depends on it.
+func PythonUninstallPlan(purge bool) (*Plan, error) {
+	version := ""
+	if record, ok := GetInstallRecord("python"); ok {
+		version = record.Version
+	}
+	if version == "" {
+		detected, err := utils.CommandOutput("python3", "-c", `import sys; print("%d.%d" % sys.version_info[:2])`)
+		if err != nil || detected == "" {
+			return nil, fmt.Errorf("failed to detect the installed Python version: %w", err)
+		}
+		version = detected
+	}
+	selectedVersion := "python" + version
+
+	plan := uninstallPlan("python", uninstallSpec{
+		packages: []string{selectedVersion + "-dev", selectedVersion + "-venv"},
+		notes:    []string{fmt.Sprintf("%s itself was kept since the operating system depends on it", selectedVersion)},
+	}, purge)
+	plan.Add(
+		command("Removing python alternative", "sudo", "update-alternatives", "--remove", "python", "/usr/bin/"+selectedVersion).optional(),
+		command("Removing deadsnakes PPA", "sudo", "add-apt-repository", "--remove", "-y", "ppa:deadsnakes/ppa").optional(),
+	)
+	return plan, nil
 }
 
 func displayAndSelectPythonVersion(versions []string) (string, error) {
@@ -106,14 +137,18 @@ func displayAndSelectPythonVersion(versions []string) (string, error) {
 		return "", fmt.Errorf("no Python versions available")
 	}
 
+	if utils.AssumeYes() {
+		fmt.Printf("✅ Selected: %s (default)\n", versions[0])
+		return versions[0], nil
+	}
+
 	utils.PrintInfo("Available Python versions:")
 	for i, version := range versions {
 		fmt.Printf("%d. %s\n", i+1, version)
 	}
 
 	fmt.Print("\nSelect a version (default: 1): ")
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	input, err := utils.Stdin.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("failed to read input: %w", err)
 	}
@@ -131,25 +166,7 @@ func displayAndSelectPythonVersion(versions []string) (string, error) {
 	return versions[choice-1], nil
 }
 
-func setupPythonAlternatives(selectedVersion string) error {
-	utils.PrintInfo("Setting up Python alternatives...")
-
-	// Setup alternatives for python3
-	if err := utils.RunCommand("sudo", "update-alternatives", "--install", "/usr/bin/python3", "python3", "/usr/bin/"+selectedVersion, "1"); err != nil {
-		return fmt.Errorf("failed to setup python3 alternative: %w", err)
-	}
-
-	// Setup alternatives for python (generic)
-	if err := utils.RunCommand("sudo", "update-alternatives", "--install", "/usr/bin/python", "python", "/usr/bin/"+selectedVersion, "1"); err != nil {
-		utils.PrintWarning("Failed to setup generic python alternative, this is usually not critical")
-	}
-
-	return nil
-}
-
 func upgradePip(selectedVersion string) error {
-	utils.PrintInfo("Upgrading pip...")
-
 	// First try with the specific Python version
 	if err := utils.RunCommand(selectedVersion, "-m", "pip", "install", "--upgrade", "pip"); err != nil {
 		// Fallback to python3
@@ -160,63 +177,3 @@ func upgradePip(selectedVersion string) error {
 
 	return nil
 }
-
-func installCommonPackages() error {
-	utils.PrintInfo("Installing common Python packages...")
-
-	packages := []string{
-		"virtualenv",
-		"wheel",
-		"setuptools",
-		"requests",
-		"numpy",
-		"pandas",
-		"matplotlib",
-		"pytest",
-		"black",
-		"flake8",
-		"pipenv",
-	}
-
-	for _, pkg := range packages {
-		utils.PrintInfo(fmt.Sprintf("Installing %s...", pkg))
-		if err := utils.RunCommand("python3", "-m", "pip", "install", pkg); err != nil {
-			utils.PrintWarning(fmt.Sprintf("Failed to install %s: %v", pkg, err))
-		}
-	}
-
-	return nil
-}
-
-func displayPythonInfo(selectedVersion string) error {
-	utils.PrintInfo("Python installation summary:")
-
-	// Display Python version
-	fmt.Println("Python version:")
-	if err := utils.RunCommand("python3", "--version"); err != nil {
-		utils.PrintWarning("Failed to get Python version")
-	}
-
-	// Display pip version
-	fmt.Println("\nPip version:")
-	if err := utils.RunCommand("python3", "-m", "pip", "--version"); err != nil {
-		utils.PrintWarning("Failed to get pip version")
-	}
-
-	// Display installed location
-	fmt.Println("\nPython executable location:")
-	if err := utils.RunCommand("which", "python3"); err != nil {
-		utils.PrintWarning("Failed to get Python location")
-	}
-
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	utils.PrintSuccess("Python is ready to use!")
-	fmt.Println("Tips:")
-	fmt.Println("- Create virtual environments: python3 -m venv myenv")
-	fmt.Println("- Activate virtual environment: source myenv/bin/activate")
-	fmt.Println("- Install packages: pip install package_name")
-	fmt.Println("- Use pipenv for project management: pipenv install")
-	fmt.Println(strings.Repeat("=", 50))
-
-	return nil
-}
@@ -1,27 +1,18 @@
 package services
 
-import (
-	"fmt"
-
-	"github.com/amirkh8006/bootup-cli/internal/utils"
-)
-
-func InstallRedis() error {
-	utils.PrintInfo("Installing Redis locally...")
-	if err := utils.RunCommand("sudo", "apt-get", "install", "-y", "redis-server"); err != nil {
-		return fmt.Errorf("failed to install Redis: %w", err)
-	}
-
-	utils.PrintInfo("Enabling Redis service...")
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "redis-server"); err != nil {
-		return fmt.Errorf("failed to enable Redis service: %w", err)
-	}
-
-	utils.PrintInfo("Starting Redis service...")
-	if err := utils.RunCommand("sudo", "systemctl", "start", "redis-server"); err != nil {
-		return fmt.Errorf("failed to start Redis service: %w", err)
+// RedisPlan builds the install plan for Redis
+func RedisPlan() (*Plan, error) {
+	plan, err := packagePlan("redis", "Redis")
+	if err != nil {
+		return nil, err
 	}
+	plan.Success = "Redis installed and started successfully!"
+	return plan, nil
+}
 
-	utils.PrintSuccess("Redis installed and started successfully!")
-	return nil
+// RedisUninstallPlan builds the removal plan for Redis
+func RedisUninstallPlan(purge bool) (*Plan, error) {
+	return packageUninstallPlan("redis", uninstallSpec{
+		dataDirs: []string{"/var/lib/redis", "/etc/redis"},
+	}, purge)
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 )
 
@@ -11,169 +12,401 @@ type ServiceInfo struct {
 	Name        string
 	Description string
 	Category    string
-	Installer   func() error
+	Plan        func() (*Plan, error)
+	Uninstall   func(purge bool) (*Plan, error)
+
+	// VersionCommand prints the installed version, for services that were
+	// not installed from packages or whose package is not known
+	VersionCommand []string
+
+	// Upgrade builds an in-place upgrade to the given version. Services
+	// installed from packages leave it nil and are upgraded with the
+	// distribution's package manager.
+	Upgrade func(version string) (*Plan, error)
+
+	// Configure rewrites the configuration of an installed service with the
+	// given settings changed, for services that support `bootup configure`
+	Configure func(changes map[string]string) (*Plan, error)
+
+	// Requires lists services that must be installed first; Suggests lists
+	// services that work well alongside this one but are not installed automatically
+	Requires []string
+	Suggests []string
+
+	// DiskMB is the free space the service needs in /var/lib, 1024 MB when
+	// zero. Hosts lists download hosts the preflight checks cannot derive
+	// from the release and repository registries. Preflight adds checks of
+	// its own.
+	DiskMB    int
+	Hosts     []string
+	Preflight func() []Check
 }
 
 // serviceRegistry contains all available services and their configurations
 var serviceRegistry = map[string]ServiceInfo{
 	"nginx": {
-		Name:        "nginx",
-		Description: "High-performance web server",
-		Category:    "Web Servers",
-		Installer:   InstallNginx,
+		Name:           "nginx",
+		Description:    "High-performance web server",
+		Category:       "Web Servers",
+		Plan:           NginxPlan,
+		Uninstall:      NginxUninstallPlan,
+		VersionCommand: []string{"nginx", "-v"},
 	},
 	"caddy": {
-		Name:        "caddy",
-		Description: "Modern web server with automatic HTTPS",
-		Category:    "Web Servers",
-		Installer:   InstallCaddy,
+		Name:           "caddy",
+		Description:    "Modern web server with automatic HTTPS",
+		Category:       "Web Servers",
+		Plan:           CaddyPlan,
+		Uninstall:      CaddyUninstallPlan,
+		VersionCommand: []string{"caddy", "version"},
 	},
 	"postgresql": {
-		Name:        "postgresql",
-		Description: "Powerful relational database",
-		Category:    "Databases",
-		Installer:   InstallPostgreSQL,
+		Name:           "postgresql",
+		Description:    "Powerful relational database",
+		Category:       "Databases",
+		Plan:           PostgreSQLPlan,
+		Uninstall:      PostgreSQLUninstallPlan,
+		VersionCommand: []string{"psql", "--version"},
 	},
 	"mongodb": {
-		Name:        "mongodb",
-		Description: "NoSQL document database",
-		Category:    "Databases",
-		Installer:   InstallMongoDB,
+		Name:           "mongodb",
+		Description:    "NoSQL document database",
+		Category:       "Databases",
+		Plan:           MongoDBPlan,
+		Uninstall:      MongoDBUninstallPlan,
+		VersionCommand: []string{"mongod", "--version"},
+		DiskMB:         2048,
 	},
 	"redis": {
-		Name:        "redis",
-		Description: "In-memory data structure store",
-		Category:    "Databases",
-		Installer:   InstallRedis,
+		Name:           "redis",
+		Description:    "In-memory data structure store",
+		Category:       "Databases",
+		Plan:           RedisPlan,
+		Uninstall:      RedisUninstallPlan,
+		VersionCommand: []string{"redis-server", "--version"},
 	},
 	"elasticsearch": {
-		Name:        "elasticsearch",
-		Description: "Distributed search and analytics engine",
-		Category:    "Databases",
-		Installer:   InstallElasticsearch,
+		Name:           "elasticsearch",
+		Description:    "Distributed search and analytics engine",
+		Category:       "Databases",
+		Plan:           ElasticsearchPlan,
+		Uninstall:      ElasticsearchUninstallPlan,
+		VersionCommand: []string{"/usr/share/elasticsearch/bin/elasticsearch", "--version"},
+		DiskMB:         4096,
 	},
 	"mysql": {
-		Name:        "mysql",
-		Description: "Popular open-source relational database",
-		Category:    "Databases",
-		Installer:   InstallMySQL,
+		Name:           "mysql",
+		Description:    "Popular open-source relational database",
+		Category:       "Databases",
+		Plan:           MySQLPlan,
+		Uninstall:      MySQLUninstallPlan,
+		VersionCommand: []string{"mysql", "--version"},
 	},
 	"clickhouse": {
-		Name:        "clickhouse",
-		Description: "High-performance columnar database for analytics",
-		Category:    "Databases",
-		Installer:   InstallClickHouse,
+		Name:           "clickhouse",
+		Description:    "High-performance columnar database for analytics",
+		Category:       "Databases",
+		Plan:           ClickHousePlan,
+		Uninstall:      ClickHouseUninstallPlan,
+		VersionCommand: []string{"clickhouse", "--version"},
+		DiskMB:         4096,
 	},
 	"nodejs": {
-		Name:        "nodejs",
-		Description: "JavaScript runtime environment",
-		Category:    "Development",
-		Installer:   InstallNodeJS,
+		Name:           "nodejs",
+		Description:    "JavaScript runtime environment",
+		Category:       "Development",
+		Plan:           NodeJSPlan,
+		Uninstall:      NodeJSUninstallPlan,
+		VersionCommand: []string{"node", "--version"},
+		Hosts:          []string{"deb.nodesource.com", "nodejs.org"},
 	},
 	"golang": {
-		Name:        "golang",
-		Description: "Go programming language compiler and tools",
-		Category:    "Development",
-		Installer:   InstallGolang,
+		Name:           "golang",
+		Description:    "Go programming language compiler and tools",
+		Category:       "Development",
+		Plan:           GolangPlan,
+		Uninstall:      GolangUninstallPlan,
+		VersionCommand: []string{"/usr/local/go/bin/go", "version"},
+		Upgrade:        GolangUpgradePlan,
+		DiskMB:         512,
+		Hosts:          []string{"go.dev", "dl.google.com"},
 	},
 	"php": {
-		Name:        "php",
-		Description: "PHP programming language and runtime",
-		Category:    "Development",
-		Installer:   InstallPHP,
+		Name:           "php",
+		Description:    "PHP programming language and runtime",
+		Category:       "Development",
+		Plan:           PHPPlan,
+		Uninstall:      PHPUninstallPlan,
+		VersionCommand: []string{"php", "-r", "echo PHP_MAJOR_VERSION.'.'.PHP_MINOR_VERSION;"},
+		Hosts:          []string{"ppa.launchpadcontent.net", "getcomposer.org"},
 	},
 	"python": {
-		Name:        "python",
-		Description: "Python programming language and interpreter",
-		Category:    "Development",
-		Installer:   InstallPython,
+		Name:           "python",
+		Description:    "Python programming language and interpreter",
+		Category:       "Development",
+		Plan:           PythonPlan,
+		Uninstall:      PythonUninstallPlan,
+		VersionCommand: []string{"python3", "--version"},
+		Hosts:          []string{"ppa.launchpadcontent.net"},
+	},
+	"java": {
+		Name:           "java",
+		Description:    "OpenJDK Java runtime and development kit",
+		Category:       "Development",
+		Plan:           JavaPlan,
+		Uninstall:      JavaUninstallPlan,
+		VersionCommand: []string{"java", "-version"},
 	},
 	"kafka": {
-		Name:        "kafka",
-		Description: "Distributed streaming platform",
-		Category:    "Message Brokers",
-		Installer:   InstallKafka,
+		Name:           "kafka",
+		Description:    "Distributed streaming platform",
+		Category:       "Message Brokers",
+		Plan:           KafkaPlan,
+		Uninstall:      KafkaUninstallPlan,
+		VersionCommand: []string{"/opt/kafka/bin/kafka-topics.sh", "--version"},
+		Upgrade:        KafkaUpgradePlan,
+		Requires:       []string{"java"},
+		DiskMB:         2048,
+		Preflight:      kafkaPreflight,
 	},
 	"rabbitmq": {
-		Name:        "rabbitmq",
-		Description: "Message broker for distributed applications",
-		Category:    "Message Brokers",
-		Installer:   InstallRabbitMQ,
+		Name:           "rabbitmq",
+		Description:    "Message broker for distributed applications",
+		Category:       "Message Brokers",
+		Plan:           RabbitMQPlan,
+		Uninstall:      RabbitMQUninstallPlan,
+		VersionCommand: []string{"rabbitmqctl", "version"},
 	},
 	"prometheus": {
-		Name:        "prometheus",
-		Description: "Monitoring and alerting toolkit",
-		Category:    "Monitoring",
-		Installer:   InstallPrometheus,
+		Name:           "prometheus",
+		Description:    "Monitoring and alerting toolkit",
+		Category:       "Monitoring",
+		Plan:           PrometheusPlan,
+		Uninstall:      PrometheusUninstallPlan,
+		VersionCommand: []string{"/opt/prometheus/prometheus", "--version"},
+		Upgrade:        PrometheusUpgradePlan,
+		Configure:      PrometheusConfigurePlan,
+		DiskMB:         2048,
 	},
 	"grafana": {
-		Name:        "grafana",
-		Description: "Analytics and monitoring platform",
-		Category:    "Monitoring",
-		Installer:   InstallGrafana,
+		Name:           "grafana",
+		Description:    "Analytics and monitoring platform",
+		Category:       "Monitoring",
+		Plan:           GrafanaPlan,
+		Uninstall:      GrafanaUninstallPlan,
+		VersionCommand: []string{"grafana-server", "-v"},
+		Suggests:       []string{"prometheus"},
 	},
 	"alertmanager": {
-		Name:        "alertmanager",
-		Description: "Handles alerts from Prometheus",
-		Category:    "Monitoring",
-		Installer:   InstallAlertmanager,
+		Name:           "alertmanager",
+		Description:    "Handles alerts from Prometheus",
+		Category:       "Monitoring",
+		Plan:           AlertmanagerPlan,
+		Uninstall:      AlertmanagerUninstallPlan,
+		VersionCommand: []string{"/opt/alertmanager/alertmanager", "--version"},
+		Upgrade:        AlertmanagerUpgradePlan,
+		Configure:      AlertmanagerConfigurePlan,
+		Suggests:       []string{"prometheus"},
 	},
 	"docker": {
-		Name:        "docker",
-		Description: "Container platform for building and running applications",
-		Category:    "Development",
-		Installer:   InstallDocker,
+		Name:           "docker",
+		Description:    "Container platform for building and running applications",
+		Category:       "Development",
+		Plan:           DockerPlan,
+		Uninstall:      DockerUninstallPlan,
+		VersionCommand: []string{"docker", "--version"},
+		DiskMB:         4096,
 	},
 	"rustfs": {
-		Name:        "rustfs",
-		Description: "High-performance object storage system",
-		Category:    "Storage",
-		Installer:   InstallRustFS,
+		Name:           "rustfs",
+		Description:    "High-performance object storage system",
+		Category:       "Storage",
+		Plan:           RustFSPlan,
+		Uninstall:      RustFSUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/rustfs", "--version"},
+		Upgrade:        RustFSUpgradePlan,
 	},
 	"seaweedfs": {
-		Name:        "seaweedfs",
-		Description: "Fast distributed storage system for blobs, objects, files, and data lake",
-		Category:    "Storage",
-		Installer:   InstallSeaweedFS,
+		Name:           "seaweedfs",
+		Description:    "Fast distributed storage system for blobs, objects, files, and data lake",
+		Category:       "Storage",
+		Plan:           SeaweedFSPlan,
+		Uninstall:      SeaweedFSUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/weed", "version"},
+		Upgrade:        SeaweedFSUpgradePlan,
 	},
 	"trivy": {
-		Name:        "trivy",
-		Description: "Vulnerability scanner for containers and other artifacts",
-		Category:    "Security",
-		Installer:   InstallTrivy,
+		Name:           "trivy",
+		Description:    "Vulnerability scanner for containers and other artifacts",
+		Category:       "Security",
+		Plan:           TrivyPlan,
+		Uninstall:      TrivyUninstallPlan,
+		VersionCommand: []string{"trivy", "--version"},
+	},
+	"mongodb_exporter": {
+		Name:           "mongodb_exporter",
+		Description:    "MongoDB metrics exporter for Prometheus",
+		Category:       "Prometheus Exporters",
+		Plan:           MongoExporterPlan,
+		Uninstall:      MongoExporterUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/mongodb_exporter", "--version"},
+		Upgrade:        MongoExporterUpgradePlan,
+		Requires:       []string{"mongodb"},
+	},
+	"nginx_exporter": {
+		Name:           "nginx_exporter",
+		Description:    "NGINX metrics exporter for Prometheus",
+		Category:       "Prometheus Exporters",
+		Plan:           NginxExporterPlan,
+		Uninstall:      NginxExporterUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/nginx-prometheus-exporter", "--version"},
+		Upgrade:        NginxExporterUpgradePlan,
+		Requires:       []string{"nginx"},
+	},
+	"node_exporter": {
+		Name:           "node_exporter",
+		Description:    "Hardware and OS metrics exporter for Prometheus",
+		Category:       "Prometheus Exporters",
+		Plan:           NodeExporterPlan,
+		Uninstall:      NodeExporterUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/node_exporter", "--version"},
+		Upgrade:        NodeExporterUpgradePlan,
+		Suggests:       []string{"prometheus"},
+	},
+	"postgres_exporter": {
+		Name:           "postgres_exporter",
+		Description:    "PostgreSQL metrics exporter for Prometheus",
+		Category:       "Prometheus Exporters",
+		Plan:           PostgresExporterPlan,
+		Uninstall:      PostgresExporterUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/postgres_exporter", "--version"},
+		Upgrade:        PostgresExporterUpgradePlan,
+		Requires:       []string{"postgresql"},
+	},
+	"redis_exporter": {
+		Name:           "redis_exporter",
+		Description:    "Redis metrics exporter for Prometheus",
+		Category:       "Prometheus Exporters",
+		Plan:           RedisExporterPlan,
+		Uninstall:      RedisExporterUninstallPlan,
+		VersionCommand: []string{"/usr/local/bin/redis_exporter", "--version"},
+		Upgrade:        RedisExporterUpgradePlan,
+		Requires:       []string{"redis"},
+	},
+}
+
+// Release describes where a service installed from a release tarball is
+// downloaded. {version} in URL and ArchiveDir is replaced with the version
+// being installed, {arch} with the architecture as Go names it (amd64,
+// arm64) and {machine} with its uname name (x86_64, aarch64).
+type Release struct {
+	DefaultVersion string
+	URL            string
+	ArchiveDir     string // directory inside the archive holding the binary, if any
+
+	// LatestURL is used when DefaultVersion is empty and no version was requested
+	LatestURL string
+
+	// ChecksumURL lists the SHA-256 (or SHA-512) digest of the download.
+	// Without it the digest GitHub publishes for the release asset is used.
+	ChecksumURL string
+
+	// GitHubRepo is the owner/name repository whose releases announce new
+	// versions; Tag is its release tag, v{version} when empty
+	GitHubRepo string
+	Tag        string
+
+	// Arches lists the architectures upstream publishes builds for; nil
+	// when the download does not depend on the architecture
+	Arches []string
+}
+
+// releaseArches are the architectures every release in the registry that
+// depends on the architecture is built for
+var releaseArches = []string{"amd64", "arm64"}
+
+// releaseRegistry lists the download templates of the tarball-installed
+// services, so any of them can be pinned with service@version
+var releaseRegistry = map[string]Release{
+	"prometheus": {
+		DefaultVersion: "3.0.1",
+		URL:            "https://github.com/prometheus/prometheus/releases/download/v{version}/prometheus-{version}.linux-{arch}.tar.gz",
+		ChecksumURL:    "https://github.com/prometheus/prometheus/releases/download/v{version}/sha256sums.txt",
+		GitHubRepo:     "prometheus/prometheus",
+		Arches:         releaseArches,
+	},
+	"alertmanager": {
+		DefaultVersion: "0.28.1",
+		URL:            "https://github.com/prometheus/alertmanager/releases/download/v{version}/alertmanager-{version}.linux-{arch}.tar.gz",
+		ChecksumURL:    "https://github.com/prometheus/alertmanager/releases/download/v{version}/sha256sums.txt",
+		GitHubRepo:     "prometheus/alertmanager",
+		Arches:         releaseArches,
+	},
+	"kafka": {
+		DefaultVersion: "4.1.0",
+		URL:            "https://archive.apache.org/dist/kafka/{version}/kafka_2.13-{version}.tgz",
+		ChecksumURL:    "https://archive.apache.org/dist/kafka/{version}/kafka_2.13-{version}.tgz.sha512",
+	},
+	"seaweedfs": {
+		URL:        "https://github.com/seaweedfs/seaweedfs/releases/download/{version}/linux_{arch}.tar.gz",
+		LatestURL:  "https://github.com/seaweedfs/seaweedfs/releases/latest/download/linux_{arch}.tar.gz",
+		GitHubRepo: "seaweedfs/seaweedfs",
+		Arches:     releaseArches,
+	},
+	"rustfs": {
+		URL:        "https://github.com/rustfs/rustfs/releases/download/{version}/rustfs-linux-{machine}-musl-v{version}.zip",
+		LatestURL:  "https://github.com/rustfs/rustfs/releases/latest/download/rustfs-linux-{machine}-musl-latest.zip",
+		GitHubRepo: "rustfs/rustfs",
+		Tag:        "{version}",
+		Arches:     releaseArches,
 	},
 	"mongodb_exporter": {
-		Name:        "mongodb_exporter",
-		Description: "MongoDB metrics exporter for Prometheus",
-		Category:    "Prometheus Exporters",
-		Installer:   InstallMongoExporter,
+		DefaultVersion: "0.47.1",
+		URL:            "https://github.com/percona/mongodb_exporter/releases/download/v{version}/mongodb_exporter-{version}.linux-{arch}.tar.gz",
+		ArchiveDir:     "mongodb_exporter-{version}.linux-{arch}",
+		GitHubRepo:     "percona/mongodb_exporter",
+		Arches:         releaseArches,
 	},
 	"nginx_exporter": {
-		Name:        "nginx_exporter",
-		Description: "NGINX metrics exporter for Prometheus",
-		Category:    "Prometheus Exporters",
-		Installer:   InstallNginxExporter,
+		DefaultVersion: "1.5.0",
+		URL:            "https://github.com/nginxinc/nginx-prometheus-exporter/releases/download/v{version}/nginx-prometheus-exporter_{version}_linux_{arch}.tar.gz",
+		ChecksumURL:    "https://github.com/nginxinc/nginx-prometheus-exporter/releases/download/v{version}/nginx-prometheus-exporter_{version}_checksums.txt",
+		GitHubRepo:     "nginxinc/nginx-prometheus-exporter",
+		Arches:         releaseArches,
 	},
 	"node_exporter": {
-		Name:        "node_exporter",
-		Description: "Hardware and OS metrics exporter for Prometheus",
-		Category:    "Prometheus Exporters",
-		Installer:   InstallNodeExporter,
+		DefaultVersion: "1.9.1",
+		URL:            "https://github.com/prometheus/node_exporter/releases/download/v{version}/node_exporter-{version}.linux-{arch}.tar.gz",
+		ChecksumURL:    "https://github.com/prometheus/node_exporter/releases/download/v{version}/sha256sums.txt",
+		ArchiveDir:     "node_exporter-{version}.linux-{arch}",
+		GitHubRepo:     "prometheus/node_exporter",
+		Arches:         releaseArches,
 	},
 	"postgres_exporter": {
-		Name:        "postgres_exporter",
-		Description: "PostgreSQL metrics exporter for Prometheus",
-		Category:    "Prometheus Exporters",
-		Installer:   InstallPostgresExporter,
+		DefaultVersion: "0.17.1",
+		URL:            "https://github.com/prometheus-community/postgres_exporter/releases/download/v{version}/postgres_exporter-{version}.linux-{arch}.tar.gz",
+		ChecksumURL:    "https://github.com/prometheus-community/postgres_exporter/releases/download/v{version}/sha256sums.txt",
+		ArchiveDir:     "postgres_exporter-{version}.linux-{arch}",
+		GitHubRepo:     "prometheus-community/postgres_exporter",
+		Arches:         releaseArches,
 	},
 	"redis_exporter": {
-		Name:        "redis_exporter",
-		Description: "Redis metrics exporter for Prometheus",
-		Category:    "Prometheus Exporters",
-		Installer:   InstallRedisExporter,
+		DefaultVersion: "1.77.0",
+		URL:            "https://github.com/oliver006/redis_exporter/releases/download/v{version}/redis_exporter-v{version}.linux-{arch}.tar.gz",
+		ChecksumURL:    "https://github.com/oliver006/redis_exporter/releases/download/v{version}/sha256sums.txt",
+		ArchiveDir:     "redis_exporter-v{version}.linux-{arch}",
+		GitHubRepo:     "oliver006/redis_exporter",
+		Arches:         releaseArches,
 	},
 }
 
+// systemdServices run from a systemd unit bootup writes itself, for which
+// there is no OpenRC service script
+var systemdServices = []string{
+	"prometheus", "alertmanager", "kafka", "seaweedfs", "rustfs",
+	"mongodb_exporter", "nginx_exporter", "node_exporter", "postgres_exporter", "redis_exporter",
+}
+
 // GetAllServices returns a list of all available services
 func GetAllServices() []ServiceInfo {
 	services := make([]ServiceInfo, 0, len(serviceRegistry))
@@ -192,13 +425,66 @@ func GetServiceNames() []string {
 	return names
 }
 
-// GetServiceInstaller returns the installer function for a service
-func GetServiceInstaller(serviceName string) (func() error, error) {
+// GetServicePlan builds the install plan for a service using the options
+// set with SetInstallOptions
+func GetServicePlan(serviceName string) (*Plan, error) {
+	service, exists := serviceRegistry[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("service %s is not supported", serviceName)
+	}
+	if version := requestedVersion(serviceName); version != "" && !validVersion.MatchString(version) {
+		return nil, fmt.Errorf("invalid version %q for %s", version, serviceName)
+	}
+	if err := CheckSupported(serviceName); err != nil {
+		return nil, err
+	}
+	plan, err := service.Plan()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOptions(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// GetServiceUninstallPlan builds the removal plan for a service. With purge
+// set, data and configuration directories are deleted as well.
+func GetServiceUninstallPlan(serviceName string, purge bool) (*Plan, error) {
 	service, exists := serviceRegistry[serviceName]
 	if !exists {
 		return nil, fmt.Errorf("service %s is not supported", serviceName)
 	}
-	return service.Installer, nil
+	return service.Uninstall(purge)
+}
+
+// GetServiceInstaller returns the installer function for a service, which
+// installs any missing prerequisites and then the service itself
+func GetServiceInstaller(serviceName string) (func() error, error) {
+	if !IsValidService(serviceName) {
+		return nil, fmt.Errorf("service %s is not supported", serviceName)
+	}
+	return func() error {
+		return InstallServices([]string{serviceName})
+	}, nil
+}
+
+// CheckSupported fails when a service cannot be installed on this host: its
+// packages are not published for the distribution release, its release
+// binaries not for the architecture, or it runs from a systemd unit and the
+// host uses OpenRC
+func CheckSupported(serviceName string) error {
+	if _, ok := packageRegistry[serviceName]; ok {
+		if _, _, err := distroFor(serviceName); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(systemdServices, serviceName) {
+		if err := requireSystemd(serviceName); err != nil {
+			return err
+		}
+	}
+	return checkReleaseArch(serviceName)
 }
 
 // IsValidService checks if a service name is valid
@@ -239,8 +525,13 @@ func GetCategoryOrder() []string {
 	}
 }
 
-// IsServiceInstalled checks if a service is installed on the system
+// IsServiceInstalled checks if a service is installed on the system, either
+// by bootup according to the state file or by other means
 func IsServiceInstalled(serviceName string) bool {
+	if _, recorded := GetInstallRecord(serviceName); recorded {
+		return true
+	}
+
 	switch serviceName {
 	case "docker":
 		return isCommandAvailable("docker")
@@ -268,6 +559,8 @@ func IsServiceInstalled(serviceName string) bool {
 		return isCommandAvailable("php")
 	case "python":
 		return isCommandAvailable("python3") || isCommandAvailable("python")
+	case "java":
+		return isCommandAvailable("java")
 	case "kafka":
 		return isCommandAvailable("kafka-server-start") || isServiceRunning("kafka")
 	case "rabbitmq":
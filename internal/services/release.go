@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// releaseDownload is a release resolved to the version being installed
+type releaseDownload struct {
+	Version    string // empty when installing the latest release
+	URL        string
+	ArchiveDir string
+
+	ChecksumURL string // checksum file listing the download, if upstream publishes one
+	GitHubRepo  string
+	Tag         string // GitHub release tag, "latest" when Version is empty
+}
+
+// validVersion limits versions to characters that are safe in URLs and paths
+var validVersion = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._+-]*$`)
+
+// selectRelease resolves the download of a tarball service, using the
+// version requested with service@version or the registry default
+func selectRelease(serviceName string) releaseDownload {
+	return releaseVersion(serviceName, requestedVersion(serviceName))
+}
+
+// releaseVersion resolves the download of a specific version of a tarball
+// service, falling back to the registry default when version is empty. The
+// architecture has been checked with checkReleaseArch by then.
+func releaseVersion(serviceName, version string) releaseDownload {
+	release := releaseRegistry[serviceName]
+	arch, _ := platform.DetectArch()
+
+	version = normalizeVersion(version)
+	if version == "" {
+		version = release.DefaultVersion
+	}
+	if version == "" {
+		latestURL := strings.NewReplacer("{arch}", arch.Name, "{machine}", arch.Machine).Replace(release.LatestURL)
+		return releaseDownload{URL: latestURL, GitHubRepo: release.GitHubRepo, Tag: "latest"}
+	}
+
+	tag := release.Tag
+	if tag == "" {
+		tag = "v{version}"
+	}
+
+	expand := strings.NewReplacer("{version}", version, "{arch}", arch.Name, "{machine}", arch.Machine).Replace
+	return releaseDownload{
+		Version:     version,
+		URL:         expand(release.URL),
+		ArchiveDir:  expand(release.ArchiveDir),
+		ChecksumURL: expand(release.ChecksumURL),
+		GitHubRepo:  release.GitHubRepo,
+		Tag:         expand(tag),
+	}
+}
+
+// checkReleaseArch fails when a tarball service publishes no build for the
+// architecture bootup runs on, before anything is downloaded
+func checkReleaseArch(serviceName string) error {
+	release, ok := releaseRegistry[serviceName]
+	if !ok || release.Arches == nil {
+		return nil
+	}
+	arch, err := platform.DetectArch()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(release.Arches, arch.Name) {
+		return fmt.Errorf("%s is not published for %s, only for %s", serviceName, arch, strings.Join(release.Arches, " and "))
+	}
+	return nil
+}
+
+// download returns the step fetching the release archive to path, verified
+// against the upstream checksum file or the digest GitHub keeps for the asset
+func (r releaseDownload) download(description, archive string) Step {
+	asset := path.Base(r.URL)
+	if r.ChecksumURL != "" {
+		return download(description, r.URL, archive, r.ChecksumURL, func() (string, error) {
+			return utils.FetchChecksum(r.ChecksumURL, asset)
+		})
+	}
+	return download(description, r.URL, archive, fmt.Sprintf("GitHub release %s %s", r.GitHubRepo, r.Tag), func() (string, error) {
+		if r.GitHubRepo == "" {
+			return "", fmt.Errorf("no checksum is published for %s", asset)
+		}
+		return utils.GitHubAssetChecksum(r.GitHubRepo, r.Tag, asset)
+	})
+}
+
+// SupportsVersionPinning reports whether a service is installed from a
+// release tarball whose version can be chosen
+func SupportsVersionPinning(serviceName string) bool {
+	_, ok := releaseRegistry[serviceName]
+	return ok
+}
+
+// ParseServiceVersion splits a "service@version" argument into its parts
+func ParseServiceVersion(arg string) (string, string, error) {
+	name, version, pinned := strings.Cut(arg, "@")
+	if !pinned {
+		return name, "", nil
+	}
+	if !validVersion.MatchString(version) {
+		return "", "", fmt.Errorf("invalid version %q for %s", version, name)
+	}
+	return name, version, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// rollbackEnabled controls whether a failed install undoes the steps it already applied
+var rollbackEnabled = true
+
+// SetRollback enables or disables rolling back failed installs
+func SetRollback(enabled bool) {
+	rollbackEnabled = enabled
+}
+
+// undoAction reverts a single applied step
+type undoAction struct {
+	description string
+	run         func() error
+}
+
+// prepareUndo inspects the host before the step runs and returns the action
+// that puts it back the way it was, or nil when the step cannot be undone.
+// Anything that already existed before the step is left alone or restored
+// to its previous content, never deleted.
+func (s Step) prepareUndo() *undoAction {
+	undo := s.undoKind()
+	if s.afterUndo == nil {
+		return undo
+	}
+	if undo == nil {
+		return &undoAction{s.Description, s.afterUndo}
+	}
+	return &undoAction{undo.description, func() error {
+		if err := undo.run(); err != nil {
+			return err
+		}
+		return s.afterUndo()
+	}}
+}
+
+// undoKind returns the undo action for the kind of step
+func (s Step) undoKind() *undoAction {
+	switch s.Kind {
+	case StepAptKey, StepRepo, StepWriteFile:
+		return restoreFile(s.Path)
+
+	case StepAptPPA:
+		return &undoAction{fmt.Sprintf("Removing %s", s.PPA), func() error {
+			return utils.RunCommand("sudo", "add-apt-repository", "--remove", "-y", s.PPA)
+		}}
+
+	case StepSystemdUnit:
+		// Nothing is written on OpenRC hosts
+		if requireSystemd(s.Units[0]) != nil {
+			return nil
+		}
+		unitFile := filepath.Join("/etc/systemd/system", s.Units[0]+".service")
+		restore := restoreFile(unitFile)
+		if restore == nil {
+			return nil
+		}
+		return &undoAction{restore.description, func() error {
+			if err := restore.run(); err != nil {
+				return err
+			}
+			return utils.RunCommand("sudo", "systemctl", "daemon-reload")
+		}}
+
+	case StepDirectory, StepExtract, StepDownload:
+		if pathExists(s.Path) {
+			return nil
+		}
+		return &undoAction{fmt.Sprintf("Removing %s", s.Path), func() error {
+			return utils.RunCommand("sudo", "rm", "-rf", s.Path)
+		}}
+
+	case StepPackageInstall:
+		pm, err := packageManager()
+		if err != nil {
+			return nil
+		}
+		var added []string
+		for _, pkg := range s.Packages {
+			if !pm.Installed(pkg) {
+				added = append(added, pkg)
+			}
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		return &undoAction{"Removing installed packages", func() error {
+			return runCommand(pm.RemoveCommand(added, true))
+		}}
+
+	case StepCreateUser:
+		if utils.CheckCommand("id", s.User) {
+			return nil
+		}
+		return &undoAction{fmt.Sprintf("Deleting %s user", s.User), func() error {
+			return removeUser(s.User)
+		}}
+
+	case StepEnableStart:
+		host, err := platform.Detect()
+		if err != nil {
+			return nil
+		}
+		var fresh []string
+		for _, unit := range s.Units {
+			if !host.ServiceEnabled(unit) {
+				fresh = append(fresh, unit)
+			}
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
+		return &undoAction{"Stopping and disabling service", func() error {
+			return stopDisable(fresh...).Apply()
+		}}
+	}
+
+	return nil
+}
+
+// rollback runs the undo actions in reverse order. Failures are reported but
+// do not stop the remaining actions from running.
+func rollback(undos []*undoAction) {
+	if len(undos) == 0 {
+		return
+	}
+
+	utils.PrintWarning("Rolling back the changes made so far...")
+	for i := len(undos) - 1; i >= 0; i-- {
+		undo := undos[i]
+		utils.PrintInfo(fmt.Sprintf("Undo: %s...", undo.description))
+		if err := undo.run(); err != nil {
+			utils.PrintWarning(fmt.Sprintf("Undo of %q failed: %v", undo.description, err))
+		}
+	}
+	utils.PrintInfo("Rollback finished")
+}
+
+// restoreFile returns an undo action that deletes path if it does not exist
+// yet, or rewrites its current content if it does
+func restoreFile(path string) *undoAction {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &undoAction{fmt.Sprintf("Removing %s", path), func() error {
+			return utils.RunCommand("sudo", "rm", "-f", path)
+		}}
+	}
+	if err != nil {
+		return nil
+	}
+
+	previous, err := os.ReadFile(path)
+	if err != nil {
+		// Unreadable files are left as they are rather than deleted
+		return nil
+	}
+	return &undoAction{fmt.Sprintf("Restoring %s", path), func() error {
+		return utils.WriteFile(path, string(previous), info.Mode().Perm())
+	}}
+}
+
+// pathExists reports whether path exists. Paths that cannot be inspected are
+// treated as existing so they are never removed.
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
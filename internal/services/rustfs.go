@@ -3,82 +3,179 @@ package services
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 
+	"github.com/amirkh8006/bootup-cli/internal/secrets"
 	"github.com/amirkh8006/bootup-cli/internal/utils"
 )
 
-func InstallRustFS() error {
-	// Check if RustFS is already installed
+const (
+	rustfsArchive = "/tmp/rustfs.zip"
+	rustfsBinary  = "/usr/local/bin/rustfs"
+	rustfsEnvFile = "/etc/default/rustfs"
+	rustfsLogDir  = "/var/logs/rustfs"
+)
+
+// RustFSPlan builds the install plan for RustFS, or an upgrade plan when it is
+// already installed. The S3 port, console port and data directory come from
+// the port, console_port and data_dir settings.
+func RustFSPlan() (*Plan, error) {
 	if isRustFSInstalled() {
-		utils.PrintInfo("RustFS is already installed")
-		utils.PrintInfo("Attempting to upgrade RustFS...")
-		return upgradeRustFS()
+		utils.PrintInfo("RustFS is already installed, planning an upgrade instead")
+		return RustFSUpgradePlan(requestedVersion("rustfs"))
 	}
 
-	utils.PrintInfo("Downloading RustFS installer...")
+	port, err := servicePort("rustfs", "port")
+	if err != nil {
+		return nil, err
+	}
+	consolePort, err := servicePort("rustfs", "console_port")
+	if err != nil {
+		return nil, err
+	}
+	dataDir := setting("rustfs", "data_dir", "/data/rustfs0")
 
-	// Download the installation script
-	if err := utils.RunCommand("curl", "-O", "https://rustfs.com/install_rustfs.sh"); err != nil {
-		return fmt.Errorf("failed to download RustFS installer: %w", err)
+	if port == consolePort {
+		return nil, fmt.Errorf("RustFS port and console port must differ, both are %d", port)
+	}
+	if !filepath.IsAbs(dataDir) {
+		return nil, fmt.Errorf("RustFS data directory must be an absolute path, got %q", dataDir)
 	}
 
-	utils.PrintInfo("Installing RustFS...")
+	release := selectRelease("rustfs")
+	settings := rustfsSettings{port: port, consolePort: consolePort, dataDir: dataDir}
+	credentials := serviceCredentials("rustfs")
+
+	serviceContent := `[Unit]
+Description=RustFS Object Storage Server
+Documentation=https://rustfs.com/docs/
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+User=root
+Group=root
+EnvironmentFile=` + rustfsEnvFile + `
+ExecStart=` + rustfsBinary + ` $RUSTFS_VOLUMES
+LimitNOFILE=1048576
+Restart=always
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`
+
+	plan := NewPlan("rustfs")
+	plan.Version = release.Version
+	plan.Config = settings.config()
+	plan.Add(
+		packageInstall("Installing unzip", "unzip"),
+		release.download(rustfsDownloadDescription(release), rustfsArchive),
+		command("Installing RustFS binary", "sudo", "unzip", "-o", rustfsArchive, "rustfs", "-d", filepath.Dir(rustfsBinary)),
+		command("Making RustFS executable", "sudo", "chmod", "+x", rustfsBinary),
+		directory("Creating RustFS data directory", dataDir, ""),
+		directory("Creating RustFS log directory", rustfsLogDir, ""),
+		writeFile("Writing RustFS configuration", rustfsEnvFile, rustfsEnvContent(settings, credentials), 0600),
+		storeCredentialsStep("rustfs", credentials),
+		systemdUnit("rustfs", serviceContent),
+		enableStart("rustfs"),
+		command("Cleaning up downloaded archive", "rm", "-f", rustfsArchive).optional(),
+	)
+	plan.Success = "RustFS installed successfully!"
+	plan.Note(
+		fmt.Sprintf("RustFS S3 API is available at http://localhost:%d", port),
+		fmt.Sprintf("RustFS console is available at http://localhost:%d", consolePort),
+		credentialsNote("rustfs"),
+	)
+	return plan, nil
+}
+
+// rustfsSettings are the settings rendered into the RustFS environment file
+type rustfsSettings struct {
+	port        int
+	consolePort int
+	dataDir     string
+}
 
-	// Make the script executable
-	if err := utils.RunCommand("chmod", "+x", "install_rustfs.sh"); err != nil {
-		return fmt.Errorf("failed to make installer executable: %w", err)
-	}
+// config returns the settings as recorded in the state file
+func (s rustfsSettings) config() map[string]string {
+	return map[string]string{"port": strconv.Itoa(s.port), "console_port": strconv.Itoa(s.consolePort), "data_dir": s.dataDir}
+}
 
-	// Run the script with sudo and automatically provide responses:
-	// - Choice: 1 (Install)
-	// - Port: 9000 (default)
-	// - Console Port: 9001 (default)
-	// - Data Directory: /data/rustfs0 (default)
-	installInput := "1\n9000\n9001\n/data/rustfs0\n"
-	if err := utils.RunCommandShell(fmt.Sprintf("echo -e '%s' | sudo bash install_rustfs.sh", installInput)); err != nil {
-		return fmt.Errorf("failed to install RustFS: %w", err)
+// installedRustFSSettings returns the settings RustFS was installed with,
+// or the defaults when it was not installed by bootup
+func installedRustFSSettings() rustfsSettings {
+	s := rustfsSettings{port: 9000, consolePort: 9001, dataDir: "/data/rustfs0"}
+	for _, port := range installedPorts("rustfs") {
+		switch port.Name {
+		case "port":
+			s.port = port.Number
+		case "console_port":
+			s.consolePort = port.Number
+		}
 	}
-
-	// Clean up the installer script
-	if err := os.Remove("install_rustfs.sh"); err != nil {
-		utils.PrintWarning(fmt.Sprintf("Failed to clean up installer script: %v", err))
+	if record, ok := GetInstallRecord("rustfs"); ok && record.Config["data_dir"] != "" {
+		s.dataDir = record.Config["data_dir"]
 	}
+	return s
+}
 
-	utils.PrintSuccess("RustFS installed successfully!")
-	return nil
+// rustfsEnvContent renders /etc/default/rustfs
+func rustfsEnvContent(s rustfsSettings, credentials secrets.Credentials) string {
+	return fmt.Sprintf(`RUSTFS_ACCESS_KEY=%s
+RUSTFS_SECRET_KEY=%s
+RUSTFS_VOLUMES="%s"
+RUSTFS_ADDRESS=":%d"
+RUSTFS_CONSOLE_ENABLE=true
+RUSTFS_CONSOLE_ADDRESS=":%d"
+RUSTFS_OBS_LOG_DIRECTORY="%s"
+`, credentials["access_key"], credentials["secret_key"], s.dataDir, s.port, s.consolePort, rustfsLogDir)
+}
+
+func rustfsDownloadDescription(release releaseDownload) string {
+	if release.Version == "" {
+		return "Downloading the latest RustFS release"
+	}
+	return fmt.Sprintf("Downloading RustFS %s", release.Version)
 }
 
 func isRustFSInstalled() bool {
 	// Check if the RustFS binary exists at the expected location
-	if _, err := os.Stat("/usr/local/bin/rustfs"); err == nil {
+	if _, err := os.Stat(rustfsBinary); err == nil {
 		return true
 	}
 	return false
 }
 
-func upgradeRustFS() error {
-	utils.PrintInfo("Downloading RustFS installer...")
-
-	// Download the installation script
-	if err := utils.RunCommand("curl", "-O", "https://rustfs.com/install_rustfs.sh"); err != nil {
-		return fmt.Errorf("failed to download RustFS installer: %w", err)
-	}
-
-	// Make the script executable
-	if err := utils.RunCommand("chmod", "+x", "install_rustfs.sh"); err != nil {
-		return fmt.Errorf("failed to make installer executable: %w", err)
-	}
-
-	// Run the script with sudo and choose option 3 (Upgrade)
-	if err := utils.RunCommandShell("echo '3' | sudo bash install_rustfs.sh"); err != nil {
-		return fmt.Errorf("failed to upgrade RustFS: %w", err)
-	}
+// RustFSUpgradePlan replaces the RustFS binary with another release, keeping
+// its configuration and data
+func RustFSUpgradePlan(version string) (*Plan, error) {
+	release := releaseVersion("rustfs", version)
+
+	plan := NewPlan("rustfs")
+	plan.Version = release.Version
+	plan.Add(
+		packageInstall("Installing unzip", "unzip"),
+		release.download(rustfsDownloadDescription(release), rustfsArchive),
+		stopService("rustfs"),
+		command("Installing RustFS binary", "sudo", "unzip", "-o", rustfsArchive, "rustfs", "-d", filepath.Dir(rustfsBinary)),
+		startService("rustfs"),
+		command("Cleaning up downloaded archive", "rm", "-f", rustfsArchive).optional(),
+	)
+	plan.Success = "RustFS upgraded successfully!"
+	return plan, nil
+}
 
-	// Clean up the installer script
-	if err := os.Remove("install_rustfs.sh"); err != nil {
-		utils.PrintWarning(fmt.Sprintf("Failed to clean up installer script: %v", err))
-	}
+// RustFSUninstallPlan builds the removal plan for RustFS
+func RustFSUninstallPlan(purge bool) (*Plan, error) {
+	dataDir := installedRustFSSettings().dataDir
 
-	utils.PrintSuccess("RustFS upgraded successfully!")
-	return nil
+	return uninstallPlan("rustfs", uninstallSpec{
+		units:     []string{"rustfs"},
+		unitFiles: []string{"rustfs"},
+		files:     []string{rustfsBinary},
+		dataDirs:  []string{dataDir, rustfsLogDir, rustfsEnvFile},
+	}, purge), nil
 }
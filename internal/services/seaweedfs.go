@@ -4,57 +4,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/amirkh8006/bootup-cli/internal/utils"
+	"strconv"
 )
 
-// InstallSeaweedFS installs SeaweedFS distributed file system
-func InstallSeaweedFS() error {
-	utils.PrintInfo("Installing SeaweedFS...")
-
-	// Otherwise install the binary directly
-	utils.PrintInfo("Installing SeaweedFS binary...")
-	return installSeaweedFSBinary()
-}
-
-// installSeaweedFSBinary installs SeaweedFS binary directly
-func installSeaweedFSBinary() error {
-	// Create binary directory
-	binDir := "/usr/local/bin"
-
-	// Download the latest SeaweedFS binary
-	utils.PrintInfo("Downloading SeaweedFS binary...")
-	downloadURL := "https://github.com/seaweedfs/seaweedfs/releases/latest/download/linux_amd64.tar.gz"
-
-	if err := utils.RunCommand("wget", "-O", "/tmp/seaweedfs.tar.gz", downloadURL); err != nil {
-		return fmt.Errorf("failed to download SeaweedFS: %w", err)
-	}
-
-	// Extract the binary
-	utils.PrintInfo("Extracting SeaweedFS binary...")
-	if err := utils.RunCommand("tar", "-xzf", "/tmp/seaweedfs.tar.gz", "-C", "/tmp/"); err != nil {
-		return fmt.Errorf("failed to extract SeaweedFS: %w", err)
-	}
-
-	// Install the binary
-	utils.PrintInfo("Installing SeaweedFS binary...")
-	if err := utils.RunCommand("sudo", "mv", "/tmp/weed", filepath.Join(binDir, "weed")); err != nil {
-		return fmt.Errorf("failed to install SeaweedFS binary: %w", err)
-	}
-
-	if err := utils.RunCommand("sudo", "chmod", "+x", filepath.Join(binDir, "weed")); err != nil {
-		return fmt.Errorf("failed to make SeaweedFS executable: %w", err)
-	}
+const (
+	seaweedfsTarball = "/tmp/seaweedfs.tar.gz"
+	seaweedfsDataDir = "/var/lib/seaweedfs"
+)
 
-	// Create SeaweedFS data directory
-	dataDir := "/var/lib/seaweedfs"
-	utils.PrintInfo("Creating SeaweedFS data directory...")
-	if err := utils.RunCommand("sudo", "mkdir", "-p", dataDir); err != nil {
-		return fmt.Errorf("failed to create SeaweedFS data directory: %w", err)
+// SeaweedFSPlan builds the install plan for the SeaweedFS distributed file system
+func SeaweedFSPlan() (*Plan, error) {
+	release := selectRelease("seaweedfs")
+
+	ports := make(map[string]int)
+	for _, name := range []string{"master_port", "volume_port", "filer_port", "s3_port", "metrics_port"} {
+		port, err := servicePort("seaweedfs", name)
+		if err != nil {
+			return nil, err
+		}
+		ports[name] = port
 	}
 
 	// Create systemd service for binary installation
-	serviceContent := `[Unit]
+	serviceContent := fmt.Sprintf(`[Unit]
 Description=SeaweedFS Distributed File System
 After=network.target
 
@@ -62,57 +34,47 @@ After=network.target
 Type=simple
 User=root
 Group=root
-ExecStart=/usr/local/bin/weed server -dir=/var/lib/seaweedfs -s3 -master.volumeSizeLimitMB=1024
-Environment=AWS_ACCESS_KEY_ID=admin
-Environment=AWS_SECRET_ACCESS_KEY=admin123
+ExecStart=/usr/local/bin/weed server -dir=/var/lib/seaweedfs -s3 -s3.config=%s -master.volumeSizeLimitMB=1024 -master.port=%d -volume.port=%d -filer.port=%d -s3.port=%d -metricsPort=%d
 Restart=always
 RestartSec=10
 
 [Install]
 WantedBy=multi-user.target
-`
+`, seaweedfsS3Config, ports["master_port"], ports["volume_port"], ports["filer_port"], ports["s3_port"], ports["metrics_port"])
 
-	servicePath := "/tmp/seaweedfs.service"
-	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
-		return fmt.Errorf("failed to write service file: %w", err)
+	plan := NewPlan("seaweedfs")
+	plan.Version = release.Version
+	plan.Config = map[string]string{"data_dir": seaweedfsDataDir}
+	for name, port := range ports {
+		plan.Config[name] = strconv.Itoa(port)
 	}
-
-	utils.PrintInfo("Installing systemd service...")
-	if err := utils.RunCommand("sudo", "mv", servicePath, "/etc/systemd/system/seaweedfs.service"); err != nil {
-		return fmt.Errorf("failed to install service file: %w", err)
+	plan.Add(
+		release.download("Downloading SeaweedFS binary", seaweedfsTarball),
+		command("Installing SeaweedFS binary", "sudo", "tar", "-xzf", seaweedfsTarball, "-C", installDir, "weed"),
+		command("Making SeaweedFS executable", "sudo", "chmod", "+x", installDir+"/weed"),
+		directory("Creating SeaweedFS data directory", seaweedfsDataDir, ""),
+	)
+	plan.Add(credentialSteps("seaweedfs", serviceCredentials("seaweedfs"))...)
+	scrape, err := scrapeTargetSteps("seaweedfs")
+	if err != nil {
+		return nil, err
 	}
-
-	// Reload systemd and enable service
-	utils.PrintInfo("Enabling SeaweedFS service...")
-	if err := utils.RunCommand("sudo", "systemctl", "daemon-reload"); err != nil {
-		return fmt.Errorf("failed to reload systemd: %w", err)
-	}
-
-	if err := utils.RunCommand("sudo", "systemctl", "enable", "seaweedfs"); err != nil {
-		return fmt.Errorf("failed to enable SeaweedFS service: %w", err)
-	}
-
-	// Start SeaweedFS service
-	utils.PrintInfo("Starting SeaweedFS service...")
-	if err := utils.RunCommand("sudo", "systemctl", "start", "seaweedfs"); err != nil {
-		return fmt.Errorf("failed to start SeaweedFS service: %w", err)
-	}
-
-	// Cleanup temporary files
-	if err := utils.RunCommand("rm", "-f", "/tmp/seaweedfs.tar.gz"); err != nil {
-		return fmt.Errorf("failed to clean up temporary files: %w", err)
-	}
-
-	utils.PrintSuccess("SeaweedFS installed and started successfully!")
-	utils.PrintInfo("SeaweedFS services are available at:")
-	utils.PrintInfo("  • Master UI: http://localhost:9333")
-	utils.PrintInfo("  • Volume Server: http://localhost:8080")
-	utils.PrintInfo("  • Filer UI: http://localhost:8888")
-	utils.PrintInfo("  • S3 API: http://localhost:8333")
-	utils.PrintInfo("  • WebDAV: http://localhost:7333")
-	utils.PrintInfo("S3 Credentials: AccessKey=admin, SecretKey=admin123")
-
-	return nil
+	plan.Add(
+		systemdUnit("seaweedfs", serviceContent),
+		enableStart("seaweedfs"),
+	)
+	plan.Add(scrape...)
+	plan.Add(command("Cleaning up temporary files", "rm", "-f", seaweedfsTarball))
+	plan.Success = "SeaweedFS installed and started successfully!"
+	plan.Note(
+		"SeaweedFS services are available at:",
+		fmt.Sprintf("  • Master UI: http://localhost:%d", ports["master_port"]),
+		fmt.Sprintf("  • Volume Server: http://localhost:%d", ports["volume_port"]),
+		fmt.Sprintf("  • Filer UI: http://localhost:%d", ports["filer_port"]),
+		fmt.Sprintf("  • S3 API: http://localhost:%d", ports["s3_port"]),
+		credentialsNote("seaweedfs"),
+	)
+	return plan, nil
 }
 
 // isSeaweedFSInstalled checks if SeaweedFS is installed on the system
@@ -134,3 +96,30 @@ func isSeaweedFSInstalled() bool {
 
 	return false
 }
+
+// SeaweedFSUninstallPlan builds the removal plan for SeaweedFS
+func SeaweedFSUninstallPlan(purge bool) (*Plan, error) {
+	return uninstallPlan("seaweedfs", uninstallSpec{
+		units:     []string{"seaweedfs"},
+		unitFiles: []string{"seaweedfs"},
+		files:     []string{installDir + "/weed", filepath.Dir(seaweedfsS3Config), prometheusTargetFile("seaweedfs")},
+		dataDirs:  []string{seaweedfsDataDir},
+	}, purge), nil
+}
+
+// SeaweedFSUpgradePlan replaces the weed binary with another release
+func SeaweedFSUpgradePlan(version string) (*Plan, error) {
+	release := releaseVersion("seaweedfs", version)
+
+	plan := NewPlan("seaweedfs")
+	plan.Version = release.Version
+	plan.Add(
+		release.download(fmt.Sprintf("Downloading SeaweedFS %s", release.Version), seaweedfsTarball),
+		stopService("seaweedfs"),
+		command("Installing SeaweedFS binary", "sudo", "tar", "-xzf", seaweedfsTarball, "-C", installDir, "weed"),
+		startService("seaweedfs"),
+		command("Cleaning up temporary files", "rm", "-f", seaweedfsTarball).optional(),
+	)
+	plan.Success = fmt.Sprintf("SeaweedFS upgraded to %s!", release.Version)
+	return plan, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/amirkh8006/bootup-cli/internal/state"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// Record summarises what the plan puts on the host, for the state file
+func (p *Plan) Record() state.Record {
+	record := state.Record{
+		Service:     p.Service,
+		Version:     p.Version,
+		InstalledAt: time.Now().UTC(),
+		Config:      p.Config,
+	}
+
+	for _, step := range p.Steps {
+		switch step.Kind {
+		case StepAptKey, StepRepo:
+			record.Repos = appendUnique(record.Repos, step.Path)
+		case StepPackageInstall:
+			record.Packages = appendUnique(record.Packages, step.Packages...)
+		case StepWriteFile:
+			record.Files = appendUnique(record.Files, step.Path)
+		case StepDirectory:
+			record.Directories = appendUnique(record.Directories, step.Path)
+		case StepExtract:
+			if !strings.HasPrefix(step.Path, os.TempDir()) {
+				record.Directories = appendUnique(record.Directories, step.Path)
+			}
+		case StepSystemdUnit:
+			record.Units = appendUnique(record.Units, step.Units...)
+			record.Files = appendUnique(record.Files, filepath.Join("/etc/systemd/system", step.Units[0]+".service"))
+		case StepEnableStart:
+			record.Units = appendUnique(record.Units, step.Units...)
+		case StepCreateUser:
+			record.Users = appendUnique(record.Users, step.User)
+		}
+	}
+
+	return record
+}
+
+// InstallService builds and executes the install plan for a service, then
+// records the result in the state file
+func InstallService(serviceName string) error {
+	plan, err := GetServicePlan(serviceName)
+	if err != nil {
+		return err
+	}
+	if err := plan.Execute(); err != nil {
+		return err
+	}
+
+	recordInstall(plan)
+	return nil
+}
+
+// GetInstallRecord returns what bootup recorded when it installed a service
+func GetInstallRecord(serviceName string) (state.Record, bool) {
+	return state.Lookup(serviceName)
+}
+
+// RecordedServices returns the services bootup installed, in alphabetical order
+func RecordedServices() []string {
+	s, err := state.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to read install state: %v", err))
+		return nil
+	}
+	return s.Names()
+}
+
+// ForgetService removes a service from the state file after it was uninstalled
+func ForgetService(serviceName string) {
+	s, err := state.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update install state: %v", err))
+		return
+	}
+	if _, ok := s.Get(serviceName); !ok {
+		return
+	}
+
+	s.Remove(serviceName)
+	if err := s.Save(); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update install state: %v", err))
+	}
+}
+
+// recordInstall stores the plan in the state file. Failing to do so does not
+// undo a successful install, so errors are only reported.
+func recordInstall(plan *Plan) {
+	s, err := state.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to record install state: %v", err))
+		return
+	}
+
+	record := plan.Record()
+	if existing, ok := s.Get(plan.Service); ok {
+		record = mergeRecord(existing, record)
+	}
+	s.Set(record)
+	if err := s.Save(); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to record install state: %v", err))
+	}
+}
+
+// mergeRecord adds what a plan for an already recorded service, such as the
+// upgrade plan RustFS builds when installed again, did to the existing
+// record, so the settings and files recorded by the first install are kept
+func mergeRecord(existing, record state.Record) state.Record {
+	if record.Version == "" {
+		record.Version = existing.Version
+	}
+	record.Packages = appendUnique(existing.Packages, record.Packages...)
+	record.Repos = appendUnique(existing.Repos, record.Repos...)
+	record.Files = appendUnique(existing.Files, record.Files...)
+	record.Directories = appendUnique(existing.Directories, record.Directories...)
+	record.Units = appendUnique(existing.Units, record.Units...)
+	record.Users = appendUnique(existing.Users, record.Users...)
+
+	config := make(map[string]string)
+	maps.Copy(config, existing.Config)
+	maps.Copy(config, record.Config)
+	record.Config = config
+	return record
+}
+
+// redactURL hides any credentials embedded in a connection string
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+const (
+	// healthTimeout bounds each health probe, so one hung service does not
+	// stall the whole status report
+	healthTimeout = 3 * time.Second
+
+	// healthWait bounds how long a plan waits for a service to come up
+	healthWait = 60 * time.Second
+)
+
+// Health is the outcome of a service's health probe
+type Health string
+
+const (
+	Healthy   Health = "healthy"
+	Unhealthy Health = "unhealthy"
+)
+
+// PortStatus is one of a service's ports and whether something listens on it
+type PortStatus struct {
+	Name      string `json:"name"`
+	Number    int    `json:"number"`
+	Listening bool   `json:"listening"`
+}
+
+// ServiceStatus is the live state of an installed service
+type ServiceStatus struct {
+	Service       string       `json:"service"`
+	Unit          string       `json:"unit,omitempty"`
+	State         string       `json:"state,omitempty"` // empty for services without a daemon, such as Go
+	PID           int          `json:"pid,omitempty"`
+	Since         time.Time    `json:"since,omitzero"`
+	UptimeSeconds int64        `json:"uptime_seconds,omitempty"`
+	Ports         []PortStatus `json:"ports,omitempty"`
+	Version       string       `json:"version,omitempty"`
+	Health        Health       `json:"health,omitempty"` // empty when the service has nothing to probe
+	HealthDetail  string       `json:"health_detail,omitempty"`
+}
+
+// OK reports whether the service is running and passed its health probe
+func (s ServiceStatus) OK() bool {
+	running := s.State == "" || s.State == "active" || s.State == "started"
+	return running && s.Health != Unhealthy
+}
+
+// healthProbe checks a service on one of its ports
+type healthProbe struct {
+	port  string // the port name in portRegistry
+	check func(port int) error
+}
+
+// healthProbes lists how to ask each service whether it works. Services
+// with ports and no entry here are probed by connecting to their first port.
+var healthProbes = map[string]healthProbe{
+	"prometheus":        {"port", prometheusProbe},
+	"alertmanager":      {"port", httpProbe("/-/healthy")},
+	"grafana":           {"port", httpProbe("/api/health")},
+	"redis":             {"port", redisPing},
+	"postgresql":        {"port", pgIsReady},
+	"clickhouse":        {"port", httpProbe("/ping")},
+	"rabbitmq":          {"management_port", httpProbe("/")},
+	"rustfs":            {"port", httpProbe("/health")},
+	"seaweedfs":         {"master_port", httpProbe("/cluster/status")},
+	"mongodb_exporter":  {"port", httpProbe("/metrics")},
+	"nginx_exporter":    {"port", httpProbe("/metrics")},
+	"node_exporter":     {"port", httpProbe("/metrics")},
+	"postgres_exporter": {"port", httpProbe("/metrics")},
+	"redis_exporter":    {"port", httpProbe("/metrics")},
+}
+
+// Status reports the unit state, main process, listening ports, version and
+// health of an installed service
+func Status(serviceName string) (ServiceStatus, error) {
+	if _, exists := serviceRegistry[serviceName]; !exists {
+		return ServiceStatus{}, fmt.Errorf("service %s is not supported", serviceName)
+	}
+	if !IsServiceInstalled(serviceName) {
+		return ServiceStatus{}, fmt.Errorf("%s is not installed", serviceName)
+	}
+
+	status := ServiceStatus{Service: serviceName, Version: InstalledVersion(serviceName)}
+
+	if units := serviceUnits(serviceName); len(units) > 0 {
+		host, err := platform.Detect()
+		if err != nil {
+			return ServiceStatus{}, err
+		}
+		unit := host.ServiceStatus(units[0])
+		status.Unit = units[0]
+		status.State = unit.State
+		status.PID = unit.PID
+		if !unit.Since.IsZero() && unit.State == "active" {
+			status.Since = unit.Since.UTC().Truncate(time.Second)
+			status.UptimeSeconds = int64(time.Since(unit.Since).Seconds())
+		}
+	}
+
+	ports := installedPorts(serviceName)
+	if len(ports) > 0 {
+		listening, err := platform.ListeningPorts()
+		if err != nil {
+			utils.PrintWarning(fmt.Sprintf("Failed to check listening ports: %v", err))
+		}
+		for _, port := range ports {
+			status.Ports = append(status.Ports, PortStatus{port.Name, port.Number, listening[port.Number]})
+		}
+	}
+
+	if probe, port, ok := serviceProbe(serviceName, ports); ok {
+		if err := probe(port); err != nil {
+			status.Health = Unhealthy
+			status.HealthDetail = err.Error()
+		} else {
+			status.Health = Healthy
+		}
+	}
+	return status, nil
+}
+
+// serviceUnits returns the systemd units or OpenRC services that run a
+// service, as recorded at install time or as the install would create them
+func serviceUnits(serviceName string) []string {
+	if record, ok := GetInstallRecord(serviceName); ok && len(record.Units) > 0 {
+		return record.Units
+	}
+	if _, ok := packageRegistry[serviceName]; ok {
+		if distro, _, err := distroFor(serviceName); err == nil && len(distro.Units) > 0 {
+			return distro.Units
+		}
+	}
+	// Release installs name their unit after the service, and packages
+	// without listed units, such as Caddy, enable one of the same name
+	if len(portRegistry[serviceName]) > 0 {
+		return []string{serviceName}
+	}
+	return nil
+}
+
+// installedPorts returns the ports an installed service listens on, with
+// the port settings it was installed with applied
+func installedPorts(serviceName string) []Port {
+	ports := slices.Clone(portRegistry[serviceName])
+	record, ok := GetInstallRecord(serviceName)
+	if !ok {
+		return ports
+	}
+	for i, port := range ports {
+		if number, err := parsePort(record.Config[port.Name]); err == nil {
+			ports[i].Number = number
+		}
+	}
+	return ports
+}
+
+// serviceProbe returns the health probe of a service and the port to run
+// it against
+func serviceProbe(serviceName string, ports []Port) (func(int) error, int, bool) {
+	if len(ports) == 0 {
+		return nil, 0, false
+	}
+	probe, ok := healthProbes[serviceName]
+	if !ok {
+		return tcpProbe, ports[0].Number, true
+	}
+	for _, port := range ports {
+		if port.Name == probe.port {
+			return probe.check, port.Number, true
+		}
+	}
+	return nil, 0, false
+}
+
+// tcpProbe checks that the port accepts connections
+func tcpProbe(port int) error {
+	conn, err := net.DialTimeout("tcp", localAddress(port), healthTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// httpProbe returns a probe that expects a 2xx response from path
+func httpProbe(path string) func(int) error {
+	return func(port int) error {
+		return httpCheck(localAddress(port), path)
+	}
+}
+
+// httpCheck expects a 2xx response from path on address
+func httpCheck(address, path string) error {
+	client := &http.Client{Timeout: healthTimeout}
+	resp, err := client.Get("http://" + address + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GET %s returned %s", path, resp.Status)
+	}
+	return nil
+}
+
+// redisPing sends PING over the Redis protocol. A server that requires a
+// password answers NOAUTH, which still shows it is serving requests.
+func redisPing(port int) error {
+	conn, err := net.DialTimeout("tcp", localAddress(port), healthTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(healthTimeout))
+
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return fmt.Errorf("failed to send PING: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read the PING reply: %w", err)
+	}
+	reply = strings.TrimSpace(reply)
+	if reply == "+PONG" || strings.HasPrefix(reply, "-NOAUTH") {
+		return nil
+	}
+	return fmt.Errorf("PING returned %s", reply)
+}
+
+// pgIsReady asks pg_isready whether PostgreSQL accepts connections, falling
+// back to a TCP connection when the client tools are missing
+func pgIsReady(port int) error {
+	if !isCommandAvailable("pg_isready") {
+		return tcpProbe(port)
+	}
+	output, err := utils.CommandCombinedOutput("pg_isready", "-h", "127.0.0.1", "-p", strconv.Itoa(port),
+		"-t", strconv.Itoa(int(healthTimeout.Seconds())))
+	if err != nil {
+		return fmt.Errorf("pg_isready: %s", output)
+	}
+	return nil
+}
+
+func localAddress(port int) string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+// waitHealthy waits until a freshly started service passes check
+func waitHealthy(description string, check func() error) Step {
+	return action(description, func() error {
+		if utils.IsDryRun() {
+			return nil
+		}
+		deadline := time.Now().Add(healthWait)
+		for {
+			err := check()
+			if err == nil {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("not healthy after %s: %w", healthWait, err)
+			}
+			time.Sleep(time.Second)
+		}
+	})
+}
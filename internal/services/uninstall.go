@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+)
+
+// uninstallSpec lists what an install put on the host so it can be removed again
+type uninstallSpec struct {
+	units     []string // systemd units to stop and disable
+	unitFiles []string // unit files written by bootup under /etc/systemd/system
+	packages  []string // apt packages to remove
+	files     []string // binaries, install directories, apt repository lists and keyrings
+	users     []string // service users created by bootup
+	dataDirs  []string // data and configuration directories, only removed with --purge
+	notes     []string
+}
+
+// uninstallPlan turns an uninstallSpec into the ordered removal steps
+func uninstallPlan(service string, spec uninstallSpec, purge bool) *Plan {
+	// Also remove any units and repositories the state file says bootup created
+	if record, ok := GetInstallRecord(service); ok {
+		spec.units = appendUnique(spec.units, record.Units...)
+		spec.files = appendUnique(spec.files, record.Repos...)
+	}
+
+	plan := NewPlan(service)
+
+	if len(spec.units) > 0 {
+		plan.Add(stopDisable(spec.units...).optional())
+	}
+	if len(spec.unitFiles) > 0 {
+		plan.Add(removeUnit(spec.unitFiles...))
+	}
+	if len(spec.packages) > 0 {
+		plan.Add(packageRemove("Removing packages", purge, spec.packages...))
+	}
+	if len(spec.files) > 0 {
+		plan.Add(removePaths("Removing installed files", spec.files...))
+	}
+	for _, user := range spec.users {
+		plan.Add(deleteUser(user).optional())
+	}
+	if purge && len(spec.dataDirs) > 0 {
+		plan.Add(removePaths("Purging data directories", spec.dataDirs...))
+	}
+
+	plan.Success = fmt.Sprintf("%s uninstalled successfully!", service)
+	if !purge && len(spec.dataDirs) > 0 {
+		plan.Note("Data directories were kept, run again with --purge to delete them")
+	}
+	plan.Note(spec.notes...)
+	return plan
+}
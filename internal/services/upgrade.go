@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/amirkh8006/bootup-cli/internal/state"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// UpgradeResult describes what UpgradeService found and did
+type UpgradeResult struct {
+	Service   string
+	Installed string
+	Latest    string
+	Upgraded  bool
+}
+
+// UpgradeService upgrades an installed service in place to the newest
+// available version, keeping its configuration and data
+func UpgradeService(serviceName string) (UpgradeResult, error) {
+	result := UpgradeResult{Service: serviceName}
+
+	service, exists := serviceRegistry[serviceName]
+	if !exists {
+		return result, fmt.Errorf("service %s is not supported", serviceName)
+	}
+	if !IsServiceInstalled(serviceName) {
+		return result, fmt.Errorf("%s is not installed", serviceName)
+	}
+	if err := CheckSupported(serviceName); err != nil {
+		return result, err
+	}
+
+	result.Installed = InstalledVersion(serviceName)
+	if result.Installed == "" {
+		return result, fmt.Errorf("could not determine the installed %s version", serviceName)
+	}
+
+	latest, err := LatestVersion(serviceName, result.Installed)
+	if err != nil {
+		return result, err
+	}
+	result.Latest = latest
+
+	if !IsNewerVersion(serviceName, result.Installed, latest) {
+		utils.PrintSuccess(fmt.Sprintf("%s %s is up to date", serviceName, result.Installed))
+		return result, nil
+	}
+
+	utils.PrintInfo(fmt.Sprintf("Upgrading %s from %s to %s", serviceName, result.Installed, latest))
+
+	var plan *Plan
+	if service.Upgrade != nil {
+		plan, err = service.Upgrade(latest)
+	} else if packages := upgradePackages(serviceName, result.Installed); len(packages) > 0 {
+		plan, err = packageUpgradePlan(serviceName, packages)
+	} else {
+		err = fmt.Errorf("%s cannot be upgraded by bootup", serviceName)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if err := plan.Execute(); err != nil {
+		return result, err
+	}
+	result.Upgraded = true
+
+	if plan.Version != "" {
+		recordUpgrade(serviceName, plan.Version)
+	}
+	return result, nil
+}
+
+// upgradePackages returns the packages to upgrade for a packaged service
+func upgradePackages(serviceName, installed string) []string {
+	line := majorMinor(normalizeVersion(installed))
+	switch serviceName {
+	case "php":
+		return phpPackages("php" + line)
+	case "python":
+		return []string{"python" + line, "python" + line + "-dev", "python" + line + "-venv"}
+	}
+	return servicePackages(serviceName)
+}
+
+// packageUpgradePlan upgrades the installed packages of a service without
+// installing anything new
+func packageUpgradePlan(serviceName string, packages []string) (*Plan, error) {
+	pm, err := packageManager()
+	if err != nil {
+		return nil, err
+	}
+	upgrade := pm.UpgradeCommand(packages)
+
+	plan := NewPlan(serviceName)
+	plan.Add(
+		packageUpdate(),
+		command("Upgrading packages", upgrade[0], upgrade[1:]...),
+	)
+	plan.Success = fmt.Sprintf("%s upgraded successfully!", serviceName)
+	return plan, nil
+}
+
+// tarballUpgrade describes how to swap the files of a tarball-installed
+// service for another release
+type tarballUpgrade struct {
+	service string
+	title   string
+	unit    string
+	dir     string // installation directory the archive is extracted into
+	owner   string // user:group owning dir, if any
+}
+
+// tarballUpgradePlan downloads the new release before stopping the service,
+// then extracts it over the installation directory and starts it again.
+// Configuration and data live outside dir and are left untouched.
+func tarballUpgradePlan(spec tarballUpgrade, version string) *Plan {
+	release := releaseVersion(spec.service, version)
+	archive := filepath.Join(os.TempDir(), "bootup-"+spec.service+"-upgrade.tar.gz")
+
+	plan := NewPlan(spec.service)
+	plan.Version = release.Version
+	plan.Add(
+		release.download(fmt.Sprintf("Downloading %s %s", spec.title, release.Version), archive),
+		stopService(spec.unit),
+		extract(fmt.Sprintf("Extracting %s", spec.title), archive, spec.dir, 1),
+	)
+	if spec.owner != "" {
+		plan.Add(command("Setting permissions", "sudo", "chown", "-R", spec.owner, spec.dir))
+	}
+	plan.Add(
+		startService(spec.unit),
+		command("Cleaning up downloaded archive", "rm", "-f", archive).optional(),
+	)
+	plan.Success = fmt.Sprintf("%s upgraded to %s!", spec.title, release.Version)
+	return plan
+}
+
+func stopService(unit string) Step {
+	return serviceCommand("Stopping service", "stop", unit)
+}
+
+func startService(unit string) Step {
+	return serviceCommand("Starting service", "start", unit)
+}
+
+func restartService(unit string) Step {
+	return serviceCommand("Restarting service", "restart", unit)
+}
+
+// recordUpgrade stores the new version in the state file, for services bootup installed
+func recordUpgrade(serviceName, version string) {
+	s, err := state.Load()
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update install state: %v", err))
+		return
+	}
+	record, ok := s.Get(serviceName)
+	if !ok {
+		return
+	}
+
+	record.Version = version
+	s.Set(record)
+	if err := s.Save(); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Failed to update install state: %v", err))
+	}
+}
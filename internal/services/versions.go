@@ -0,0 +1,338 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/amirkh8006/bootup-cli/internal/platform"
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// versionNumber finds the first dotted version number in a tool's output
+var versionNumber = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// InstalledVersion returns the version of a service present on the host, or
+// "" when it cannot be determined
+func InstalledVersion(serviceName string) string {
+	// Packages are upgraded outside of bootup, so ask the package manager first
+	if pkg := versionPackage(serviceName); pkg != "" {
+		if pm, err := packageManager(); err == nil {
+			if version := pm.InstalledVersion(pkg); version != "" {
+				return version
+			}
+		}
+	}
+
+	if record, ok := GetInstallRecord(serviceName); ok && record.Version != "" {
+		return normalizeVersion(record.Version)
+	}
+	return probeVersion(serviceName)
+}
+
+// probeVersion asks the installed binary for its version
+func probeVersion(serviceName string) string {
+	probe := serviceRegistry[serviceName].VersionCommand
+	if len(probe) == 0 {
+		return ""
+	}
+
+	output, err := utils.CommandCombinedOutput(probe[0], probe[1:]...)
+	if err != nil {
+		return ""
+	}
+	if serviceName == "rustfs" {
+		// RustFS versions carry pre-release suffixes such as 1.0.0-alpha.58
+		if fields := strings.Fields(output); len(fields) > 1 {
+			return strings.TrimPrefix(fields[1], "v")
+		}
+	}
+	return versionNumber.FindString(output)
+}
+
+// versionPackage returns the package whose version is the service's
+// version, or "" for services that are not installed from packages
+func versionPackage(serviceName string) string {
+	switch serviceName {
+	case "php", "python":
+		// Each minor version is a separate package, e.g. php8.3 or python3.12
+		line := ""
+		if record, ok := GetInstallRecord(serviceName); ok {
+			line = record.Version
+		}
+		if line == "" {
+			line = probeVersion(serviceName)
+		}
+		if line == "" {
+			return ""
+		}
+		return serviceName + majorMinor(normalizeVersion(line))
+	}
+
+	if packages := servicePackages(serviceName); len(packages) > 0 {
+		return packages[0]
+	}
+	return ""
+}
+
+// LatestVersion looks up the newest version available for a service.
+// installed is used to stay on the same release line where that matters.
+func LatestVersion(serviceName, installed string) (string, error) {
+	switch serviceName {
+	case "golang":
+		versions, err := fetchGoVersions()
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch Go versions: %w", err)
+		}
+		for _, version := range *versions {
+			if version.Stable {
+				return normalizeVersion(version.Version), nil
+			}
+		}
+		return "", fmt.Errorf("no stable Go versions found")
+
+	case "nodejs":
+		// NodeSource repositories track a single major version
+		versions, err := fetchNodeVersions()
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch Node.js versions: %w", err)
+		}
+		major := extractMajorVersion(installed)
+		for _, version := range *versions {
+			if extractMajorVersion(version.Version) == major {
+				return normalizeVersion(version.Version), nil
+			}
+		}
+		return "", fmt.Errorf("no Node.js %s.x release found", major)
+
+	case "kafka":
+		return latestKafkaVersion()
+	}
+
+	if release, ok := releaseRegistry[serviceName]; ok && release.GitHubRepo != "" {
+		return latestGitHubRelease(release.GitHubRepo)
+	}
+	if pkg := versionPackage(serviceName); pkg != "" {
+		return packageCandidate(pkg)
+	}
+	return "", fmt.Errorf("bootup does not know where to look for new %s versions", serviceName)
+}
+
+// DefaultVersion returns the version a plain `bootup install` would install
+func DefaultVersion(serviceName string) (string, error) {
+	if release, ok := releaseRegistry[serviceName]; ok {
+		if release.DefaultVersion == "" {
+			return "latest", nil
+		}
+		return release.DefaultVersion, nil
+	}
+
+	switch serviceName {
+	case "golang":
+		return LatestVersion("golang", "")
+	case "nodejs":
+		versions, err := fetchNodeVersions()
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch Node.js versions: %w", err)
+		}
+		// --yes picks the newest LTS release
+		for _, version := range *versions {
+			if version.LTS != nil && version.LTS != false {
+				return normalizeVersion(version.Version), nil
+			}
+		}
+		return "", fmt.Errorf("no Node.js LTS release found")
+	case "php":
+		return "8.3", nil
+	case "python":
+		return "3.12", nil
+	}
+
+	if packages := servicePackages(serviceName); len(packages) > 0 {
+		return packageCandidate(packages[0])
+	}
+	return "", fmt.Errorf("unknown default version for %s", serviceName)
+}
+
+// VersionReport compares the installed version of a service with the
+// default and newest available ones
+type VersionReport struct {
+	Service   string `json:"service"`
+	Installed string `json:"installed"`
+	Default   string `json:"default"`
+	Latest    string `json:"latest"`
+	Outdated  bool   `json:"outdated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// CheckVersions builds a version report for each service. Upstream lookups
+// run concurrently since most of them are network requests.
+func CheckVersions(serviceNames []string) []VersionReport {
+	reports := make([]VersionReport, len(serviceNames))
+
+	var wg sync.WaitGroup
+	for i, name := range serviceNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = checkVersion(name)
+		}()
+	}
+	wg.Wait()
+
+	return reports
+}
+
+func checkVersion(serviceName string) VersionReport {
+	report := VersionReport{Service: serviceName, Installed: InstalledVersion(serviceName)}
+
+	var errs []string
+	if version, err := DefaultVersion(serviceName); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		report.Default = version
+	}
+	if version, err := LatestVersion(serviceName, report.Installed); err != nil {
+		// Go's default and latest come from the same lookup
+		errs = appendUnique(errs, err.Error())
+	} else {
+		report.Latest = version
+	}
+
+	report.Outdated = IsNewerVersion(serviceName, report.Installed, report.Latest)
+	report.Error = strings.Join(errs, "; ")
+	return report
+}
+
+// IsNewerVersion reports whether latest is newer than installed
+func IsNewerVersion(serviceName, installed, latest string) bool {
+	if installed == "" || latest == "" || installed == latest {
+		return false
+	}
+	// Node.js versions come from the dist index rather than the package lists
+	if host, err := platform.Detect(); err == nil && host.Family == platform.Debian &&
+		versionPackage(serviceName) != "" && serviceName != "nodejs" {
+		return utils.CheckCommand("dpkg", "--compare-versions", installed, "lt", latest)
+	}
+	return compareVersions(installed, latest) < 0
+}
+
+// compareVersions compares dotted version numbers segment by segment. As
+// in semver, a pre-release such as 1.0.0-rc.1 sorts before 1.0.0 and
+// build metadata after a + is ignored.
+func compareVersions(a, b string) int {
+	a, _, _ = strings.Cut(normalizeVersion(a), "+")
+	b, _, _ = strings.Cut(normalizeVersion(b), "+")
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+
+	as := strings.Split(aCore, ".")
+	bs := strings.Split(bCore, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return comparePreRelease(aPre, bPre)
+}
+
+// comparePreRelease orders pre-release identifiers by semver precedence:
+// numeric ones numerically and before alphanumeric ones, which compare as
+// text, and a shorter list before a longer one it is a prefix of
+func comparePreRelease(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xErr := strconv.Atoi(as[i])
+		y, yErr := strconv.Atoi(bs[i])
+		var c int
+		switch {
+		case xErr == nil && yErr == nil:
+			c = cmp.Compare(x, y)
+		case xErr == nil:
+			c = -1
+		case yErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// latestGitHubRelease returns the version of a repository's latest release
+func latestGitHubRelease(repo string) (string, error) {
+	release, err := utils.FetchGitHubRelease(repo, "latest")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(release.TagName, "v"), nil
+}
+
+// latestKafkaVersion reads the newest release from the Apache download index
+func latestKafkaVersion() (string, error) {
+	resp, err := http.Get("https://downloads.apache.org/kafka/")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Kafka releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	latest := ""
+	for _, match := range regexp.MustCompile(`href="(\d+\.\d+\.\d+)/"`).FindAllStringSubmatch(string(body), -1) {
+		if latest == "" || compareVersions(match[1], latest) > 0 {
+			latest = match[1]
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no Kafka releases found")
+	}
+	return latest, nil
+}
+
+// packageCandidate returns the version the package manager would install,
+// based on the package lists from the last refresh
+func packageCandidate(pkg string) (string, error) {
+	pm, err := packageManager()
+	if err != nil {
+		return "", err
+	}
+	return pm.CandidateVersion(pkg)
+}
+
+// majorMinor trims a version to its first two segments
+func majorMinor(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
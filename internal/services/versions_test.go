@@ -0,0 +1,71 @@
+package services
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2", "1.2.0", 0},
+		{"1.2", "1.2.1", -1},
+		{"v20.11.1", "20.11.0", 1},
+		{"go1.22.3", "1.22.10", -1},
+
+		// A pre-release sorts before its final release
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-rc.1", "0.9.9", 1},
+
+		// Numeric identifiers compare numerically and before alphanumeric ones
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-alpha", "1.0.0-1", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-alpha.1", 1},
+
+		// A shorter list of identifiers sorts first
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.1", 0},
+
+		// Build metadata is ignored
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1.0.0-rc.1+build.5", "1.0.0-rc.1+build.7", 0},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestComparePreReleaseOrder(t *testing.T) {
+	// The precedence example of the semver specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		if got := compareVersions(ordered[i], ordered[i+1]); got != -1 {
+			t.Errorf("compareVersions(%q, %q) = %d, want -1", ordered[i], ordered[i+1], got)
+		}
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := map[string]string{
+		"go1.22.3": "1.22.3",
+		"php8.3":   "8.3",
+		"v20.11.0": "20.11.0",
+		"3.12":     "3.12",
+	}
+	for version, want := range tests {
+		if got := normalizeVersion(version); got != want {
+			t.Errorf("normalizeVersion(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package stack
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed profiles/*.yaml
+var profileFiles embed.FS
+
+// Stack is a named set of services to install together
+type Stack struct {
+	Name        string    `yaml:"name"`
+	Description string    `yaml:"description"`
+	Services    []Service `yaml:"services"`
+}
+
+// Service is a single entry in a stack, with an optional version and
+// service-specific settings
+type Service struct {
+	Name     string            `yaml:"name"`
+	Version  string            `yaml:"version"`
+	Settings map[string]string `yaml:"settings"`
+}
+
+// UnmarshalYAML accepts either a bare service name or a full mapping
+func (s *Service) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Name = node.Value
+		return nil
+	}
+
+	type plain Service
+	return node.Decode((*plain)(s))
+}
+
+// Load reads a stack from a YAML file
+func Load(filename string) (*Stack, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stack file: %w", err)
+	}
+
+	stack, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stack file %s: %w", filename, err)
+	}
+	if stack.Name == "" {
+		stack.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	return stack, nil
+}
+
+// Parse decodes and validates a stack definition
+func Parse(data []byte) (*Stack, error) {
+	var stack Stack
+	if err := yaml.Unmarshal(data, &stack); err != nil {
+		return nil, err
+	}
+
+	if len(stack.Services) == 0 {
+		return nil, fmt.Errorf("no services listed")
+	}
+
+	seen := make(map[string]bool)
+	for i, service := range stack.Services {
+		// name: redis@7.2 is short for name: redis with version: 7.2
+		name, version, pinned := strings.Cut(service.Name, "@")
+		if pinned {
+			if service.Version != "" && service.Version != version {
+				return nil, fmt.Errorf("service %s pins version %s but also has version: %s", service.Name, version, service.Version)
+			}
+			service.Name, service.Version = name, version
+			stack.Services[i] = service
+		}
+		if service.Name == "" {
+			return nil, fmt.Errorf("service #%d has no name", i+1)
+		}
+		if seen[service.Name] {
+			return nil, fmt.Errorf("service %s is listed more than once", service.Name)
+		}
+		seen[service.Name] = true
+	}
+
+	return &stack, nil
+}
+
+// Profile returns one of the stacks shipped with bootup
+func Profile(name string) (*Stack, error) {
+	data, err := profileFiles.ReadFile("profiles/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown profile %s, available profiles: %s", name, strings.Join(ProfileNames(), ", "))
+	}
+	return Parse(data)
+}
+
+// ProfileNames returns the names of the built-in profiles
+func ProfileNames() []string {
+	entries, _ := profileFiles.ReadDir("profiles")
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServiceNames returns the names of the services in the stack, in order
+func (s *Stack) ServiceNames() []string {
+	names := make([]string, 0, len(s.Services))
+	for _, service := range s.Services {
+		names = append(names, service.Name)
+	}
+	return names
+}
@@ -0,0 +1,104 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		services []Service
+		err      string
+	}{
+		{
+			name:     "bare names",
+			yaml:     "services: [redis, postgresql]",
+			services: []Service{{Name: "redis"}, {Name: "postgresql"}},
+		},
+		{
+			name: "mapping with version and settings",
+			yaml: `
+services:
+  - name: prometheus
+    version: 3.0.1
+    settings:
+      retention_time: 30d
+`,
+			services: []Service{{Name: "prometheus", Version: "3.0.1", Settings: map[string]string{"retention_time": "30d"}}},
+		},
+		{
+			name:     "pinned name",
+			yaml:     "services: [prometheus@3.0.1]",
+			services: []Service{{Name: "prometheus", Version: "3.0.1"}},
+		},
+		{
+			name:     "pinned name with the same version",
+			yaml:     "services: [{name: prometheus@3.0.1, version: 3.0.1}]",
+			services: []Service{{Name: "prometheus", Version: "3.0.1"}},
+		},
+		{
+			name: "pinned name with another version",
+			yaml: "services: [{name: prometheus@3.0.1, version: 2.53.0}]",
+			err:  "pins version 3.0.1 but also has version: 2.53.0",
+		},
+		{
+			name: "duplicate",
+			yaml: "services: [redis, redis]",
+			err:  "redis is listed more than once",
+		},
+		{
+			name: "duplicate with a pinned version",
+			yaml: "services: [prometheus, prometheus@3.0.1]",
+			err:  "prometheus is listed more than once",
+		},
+		{
+			name: "missing name",
+			yaml: "services: [{version: 1.0.0}]",
+			err:  "service #1 has no name",
+		},
+		{
+			name: "no services",
+			yaml: "name: empty",
+			err:  "no services listed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack, err := Parse([]byte(tt.yaml))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(stack.Services) != len(tt.services) {
+				t.Fatalf("Parse() services = %+v, want %+v", stack.Services, tt.services)
+			}
+			for i, want := range tt.services {
+				got := stack.Services[i]
+				if got.Name != want.Name || got.Version != want.Version || len(got.Settings) != len(want.Settings) {
+					t.Errorf("service %d = %+v, want %+v", i, got, want)
+				}
+				for key, value := range want.Settings {
+					if got.Settings[key] != value {
+						t.Errorf("service %d setting %s = %q, want %q", i, key, got.Settings[key], value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	for _, name := range ProfileNames() {
+		if _, err := Profile(name); err != nil {
+			t.Errorf("Profile(%q) error = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/amirkh8006/bootup-cli/internal/utils"
+)
+
+// DefaultPath is where bootup keeps track of what it installed
+const DefaultPath = "/var/lib/bootup/state.json"
+
+// Path is the state file in use; BOOTUP_STATE_FILE overrides the default
+var Path = stateFilePath()
+
+// Record describes everything bootup did when it installed a service
+type Record struct {
+	Service     string            `json:"service"`
+	Version     string            `json:"version,omitempty"`
+	InstalledAt time.Time         `json:"installed_at"`
+	Packages    []string          `json:"packages,omitempty"`
+	Repos       []string          `json:"repos,omitempty"`
+	Files       []string          `json:"files,omitempty"`
+	Directories []string          `json:"directories,omitempty"`
+	Units       []string          `json:"units,omitempty"`
+	Users       []string          `json:"users,omitempty"`
+	Config      map[string]string `json:"config,omitempty"`
+}
+
+// State is the full content of the state file
+type State struct {
+	Services map[string]Record `json:"services"`
+}
+
+func stateFilePath() string {
+	if path := os.Getenv("BOOTUP_STATE_FILE"); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Load reads the state file, returning an empty state when it does not exist yet
+func Load() (*State, error) {
+	s := &State{Services: make(map[string]Record)}
+
+	data, err := os.ReadFile(Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", Path, err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", Path, err)
+	}
+	if s.Services == nil {
+		s.Services = make(map[string]Record)
+	}
+	return s, nil
+}
+
+// Save writes the state file through the active executor
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.RunCommand("sudo", "mkdir", "-p", filepath.Dir(Path)); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return utils.WriteFile(Path, string(data)+"\n", 0644)
+}
+
+// Get returns the record for a service
+func (s *State) Get(service string) (Record, bool) {
+	record, ok := s.Services[service]
+	return record, ok
+}
+
+// Set stores the record for its service
+func (s *State) Set(record Record) {
+	s.Services[record.Service] = record
+}
+
+// Remove forgets a service
+func (s *State) Remove(service string) {
+	delete(s.Services, service)
+}
+
+// Names returns the recorded service names in alphabetical order
+func (s *State) Names() []string {
+	names := make([]string, 0, len(s.Services))
+	for name := range s.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup loads the state file and returns the record for a service. Errors
+// reading the state are treated as the service not being recorded.
+func Lookup(service string) (Record, bool) {
+	s, err := Load()
+	if err != nil {
+		return Record{}, false
+	}
+	return s.Get(service)
+}
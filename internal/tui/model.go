@@ -12,6 +12,7 @@ type Service struct {
 	Description string
 	Category    string
 	Installed   bool
+	Version     string // Version recorded by bootup, if it installed the service
 	Installing  bool
 }
 
@@ -53,11 +54,13 @@ func NewModel() Model {
 		}
 
 		for _, serviceInfo := range categoryServices {
+			record, _ := services.GetInstallRecord(serviceInfo.Name)
 			tuiServices = append(tuiServices, Service{
 				Name:        serviceInfo.Name,
 				Description: serviceInfo.Description,
 				Category:    serviceInfo.Category,
 				Installed:   services.IsServiceInstalled(serviceInfo.Name),
+				Version:     record.Version,
 				Installing:  false,
 			})
 		}
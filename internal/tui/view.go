@@ -106,6 +106,8 @@ Press any key to close help...`
 
 		if service.Installing {
 			status = installingStyle.Render(" (installing...)")
+		} else if service.Installed && service.Version != "" {
+			status = installedStyle.Render(fmt.Sprintf(" (installed %s ✓)", service.Version))
 		} else if service.Installed {
 			status = installedStyle.Render(" (installed ✓)")
 		}
@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// DownloadFileVerified downloads a file and checks it against checksum,
+// given as "sha256:<hex>", "sha384:<hex>" or "sha512:<hex>". A file that
+// does not match is deleted and an error returned, so callers never use
+// unverified content.
+func DownloadFileVerified(url, filepath, checksum string) error {
+	if checksum == "" {
+		return fmt.Errorf("refusing to download %s without a checksum", url)
+	}
+	if err := executor.DownloadFile(url, filepath); err != nil {
+		return err
+	}
+	if IsDryRun() {
+		return nil
+	}
+
+	if err := VerifyChecksum(filepath, checksum); err != nil {
+		os.Remove(filepath)
+		return err
+	}
+	return nil
+}
+
+// VerifyChecksum compares a file's digest with checksum
+func VerifyChecksum(filepath, checksum string) error {
+	algorithm, expected, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return fmt.Errorf("invalid checksum %q", checksum)
+	}
+
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported checksum algorithm %s", algorithm)
+	}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s %s, got %s", path.Base(filepath), algorithm, expected, actual)
+	}
+	return nil
+}
+
+// FetchChecksum downloads a checksum file and returns the digest it lists
+// for filename
+func FetchChecksum(url, filename string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := parseChecksum(string(body), filename)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", url, err)
+	}
+	return digest, nil
+}
+
+// parseChecksum returns the digest a checksum file lists for filename. It
+// understands sha256sum-style listings, files holding a single digest and
+// the "name: HEX HEX ..." format used by Apache.
+func parseChecksum(content, filename string) (string, error) {
+	// Apache: "kafka_2.13-4.1.0.tgz: 6E4D 5A3C ..." spread over several lines
+	if _, digest, ok := strings.Cut(content, filename+":"); ok {
+		return checksumFromHex(strings.Join(strings.Fields(digest), ""))
+	}
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == filename {
+			return checksumFromHex(fields[0])
+		}
+	}
+
+	// A file holding nothing but the digest of the download it sits next to
+	if len(lines) == 1 {
+		if fields := strings.Fields(lines[0]); len(fields) >= 1 && (len(fields) == 1 || strings.HasSuffix(fields[1], filename)) {
+			return checksumFromHex(fields[0])
+		}
+	}
+
+	return "", fmt.Errorf("no checksum is listed for %s", filename)
+}
+
+// checksumFromHex names the algorithm of a hex digest by its length
+func checksumFromHex(digest string) (string, error) {
+	digest = strings.ToLower(digest)
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+
+	switch len(digest) {
+	case 64:
+		return "sha256:" + digest, nil
+	case 96:
+		return "sha384:" + digest, nil
+	case 128:
+		return "sha512:" + digest, nil
+	}
+	return "", fmt.Errorf("unsupported digest length %d", len(digest))
+}
+
+// GitHubRelease is the part of a GitHub release bootup needs
+type GitHubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name   string `json:"name"`
+		Digest string `json:"digest"`
+	} `json:"assets"`
+}
+
+// FetchGitHubRelease returns a release of repo by tag, or the latest release
+// when tag is "latest". GITHUB_TOKEN is used when set to avoid the anonymous
+// rate limit.
+func FetchGitHubRelease(repo, tag string) (*GitHubRelease, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/tags/%s", repo, tag)
+	if tag == "latest" {
+		url = fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s releases: %w", repo, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query %s releases: %s", repo, resp.Status)
+	}
+
+	var release GitHubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse %s releases: %w", repo, err)
+	}
+	return &release, nil
+}
+
+// GitHubAssetChecksum returns the SHA-256 digest GitHub publishes for a
+// release asset
+func GitHubAssetChecksum(repo, tag, asset string) (string, error) {
+	release, err := FetchGitHubRelease(repo, tag)
+	if err != nil {
+		return "", err
+	}
+
+	for _, a := range release.Assets {
+		if a.Name != asset {
+			continue
+		}
+		if !strings.HasPrefix(a.Digest, "sha256:") {
+			return "", fmt.Errorf("GitHub publishes no checksum for %s %s", asset, release.TagName)
+		}
+		return a.Digest, nil
+	}
+	return "", fmt.Errorf("release %s of %s has no asset %s", release.TagName, repo, asset)
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	sha256A   = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+	sha256B   = "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"
+	sha512Any = "6be1a75e91e5fc6f6b52bdaf46d7acdfee30c91acd1adde991cf7fa0263802beb5bdce0b81ba6ad77f072d27a82830bb024eeddea92c5e82f0001b69bcf460ab"
+)
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		filename string
+		want     string
+		err      string
+	}{
+		{
+			name: "sha256sums listing",
+			content: sha256A + "  prometheus-3.0.1.linux-amd64.tar.gz\n" +
+				sha256B + "  prometheus-3.0.1.linux-arm64.tar.gz\n",
+			filename: "prometheus-3.0.1.linux-arm64.tar.gz",
+			want:     "sha256:" + sha256B,
+		},
+		{
+			name:     "binary mode marker",
+			content:  sha256A + " *node_exporter-1.9.1.linux-amd64.tar.gz\n",
+			filename: "node_exporter-1.9.1.linux-amd64.tar.gz",
+			want:     "sha256:" + sha256A,
+		},
+		{
+			name:     "listing without the file",
+			content:  sha256A + "  a.tar.gz\n" + sha256B + "  b.tar.gz\n",
+			filename: "c.tar.gz",
+			err:      "no checksum is listed for c.tar.gz",
+		},
+		{
+			name:     "name that is a suffix of another",
+			content:  sha256A + "  xb.tar.gz\n" + sha256B + "  b.tar.gz\n",
+			filename: "b.tar.gz",
+			want:     "sha256:" + sha256B,
+		},
+		{
+			name:     "single digest",
+			content:  sha256A + "\n",
+			filename: "rustfs.zip",
+			want:     "sha256:" + sha256A,
+		},
+		{
+			name:     "single digest with the file name",
+			content:  sha512Any + "  /build/kafka_2.13-4.1.0.tgz\n",
+			filename: "kafka_2.13-4.1.0.tgz",
+			want:     "sha512:" + sha512Any,
+		},
+		{
+			name: "Apache sha512",
+			content: "kafka_2.13-4.1.0.tgz: 6BE1A75E 91E5FC6F 6B52BDAF 46D7ACDF EE30C91A CD1ADDE9\n" +
+				"                      91CF7FA0 263802BE B5BDCE0B 81BA6AD7 7F072D27 A82830BB\n" +
+				"                      024EEDDE A92C5E82 F0001B69 BCF460AB\n",
+			filename: "kafka_2.13-4.1.0.tgz",
+			want:     "sha512:" + sha512Any,
+		},
+		{
+			name:     "invalid digest",
+			content:  "not-hex  a.tar.gz\n",
+			filename: "a.tar.gz",
+			err:      "invalid digest",
+		},
+		{
+			name:     "unsupported length",
+			content:  "abcd  a.tar.gz\n",
+			filename: "a.tar.gz",
+			err:      "unsupported digest length 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(tt.content, tt.filename)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parseChecksum() = %q, %v, want error %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseChecksum() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
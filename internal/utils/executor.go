@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Executor performs every host-changing operation requested by the installers.
+// RunCommand, RunCommandShell and the file helpers in this package all go
+// through the active executor, so swapping it changes how an install behaves.
+type Executor interface {
+	Run(command string, args ...string) error
+	RunShell(command string) error
+	WriteFile(path, content string, perm os.FileMode) error
+	AppendFile(path, content string) error
+	DownloadFile(url, path string) error
+	ExtractTarGz(src, dest string) error
+}
+
+var executor Executor = HostExecutor{}
+
+// SetExecutor replaces the executor used by the helpers in this package
+func SetExecutor(e Executor) {
+	executor = e
+}
+
+// GetExecutor returns the executor currently in use
+func GetExecutor() Executor {
+	return executor
+}
+
+// IsDryRun reports whether commands are currently being recorded instead of executed
+func IsDryRun() bool {
+	_, ok := executor.(*DryRunExecutor)
+	return ok
+}
+
+// HostExecutor runs everything directly on the local machine
+type HostExecutor struct{}
+
+func (HostExecutor) Run(command string, args ...string) error {
+	cmd := exec.Command(command, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (HostExecutor) RunShell(command string) error {
+	cmd := exec.Command("bash", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// WriteFile writes the file directly and falls back to sudo when the
+// destination is not writable by the current user
+func (h HostExecutor) WriteFile(path, content string, perm os.FileMode) error {
+	err := os.WriteFile(path, []byte(content), perm)
+	if err == nil || !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp("", "bootup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	tmpFile.Close()
+
+	return h.Run("sudo", "install", "-m", fmt.Sprintf("%04o", perm.Perm()), tmpFile.Name(), path)
+}
+
+// AppendFile appends to the file directly and, like WriteFile, falls back
+// to sudo when it is not writable by the current user
+func (HostExecutor) AppendFile(path, content string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrPermission) {
+		cmd := exec.Command("sudo", "tee", "-a", path)
+		cmd.Stdin = strings.NewReader(content)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	return err
+}
+
+func (HostExecutor) DownloadFile(url, path string) error {
+	return downloadFile(url, path)
+}
+
+func (HostExecutor) ExtractTarGz(src, dest string) error {
+	return extractTarGz(src, dest)
+}
+
+// ActionKind describes the type of a recorded dry-run action
+type ActionKind string
+
+const (
+	ActionCommand  ActionKind = "command"
+	ActionShell    ActionKind = "shell"
+	ActionWrite    ActionKind = "write"
+	ActionAppend   ActionKind = "append"
+	ActionDownload ActionKind = "download"
+	ActionExtract  ActionKind = "extract"
+)
+
+// Action is a single operation recorded by the DryRunExecutor
+type Action struct {
+	Kind    ActionKind
+	Target  string
+	Detail  string
+	Content string
+}
+
+// DryRunExecutor records every operation without touching the host
+type DryRunExecutor struct {
+	Actions []Action
+}
+
+// NewDryRunExecutor returns an empty dry-run executor
+func NewDryRunExecutor() *DryRunExecutor {
+	return &DryRunExecutor{}
+}
+
+func (d *DryRunExecutor) record(action Action) {
+	d.Actions = append(d.Actions, action)
+}
+
+func (d *DryRunExecutor) Run(command string, args ...string) error {
+	d.record(Action{Kind: ActionCommand, Target: formatCommand(command, args...)})
+	return nil
+}
+
+func (d *DryRunExecutor) RunShell(command string) error {
+	d.record(Action{Kind: ActionShell, Target: command})
+	return nil
+}
+
+// WriteFile records the write. The content of files other users may not
+// read, such as the secrets file, is left out so the report does not print
+// passwords.
+func (d *DryRunExecutor) WriteFile(path, content string, perm os.FileMode) error {
+	detail := fmt.Sprintf("mode %04o", perm.Perm())
+	if perm&0004 == 0 {
+		detail += ", content not shown"
+		content = ""
+	}
+	d.record(Action{Kind: ActionWrite, Target: path, Detail: detail, Content: content})
+	return nil
+}
+
+func (d *DryRunExecutor) AppendFile(path, content string) error {
+	d.record(Action{Kind: ActionAppend, Target: path, Content: content})
+	return nil
+}
+
+func (d *DryRunExecutor) DownloadFile(url, path string) error {
+	d.record(Action{Kind: ActionDownload, Target: path, Detail: url})
+	return nil
+}
+
+func (d *DryRunExecutor) ExtractTarGz(src, dest string) error {
+	d.record(Action{Kind: ActionExtract, Target: dest, Detail: src})
+	return nil
+}
+
+// Files returns the paths written or appended to, in order
+func (d *DryRunExecutor) Files() []string {
+	var files []string
+	for _, action := range d.Actions {
+		if action.Kind == ActionWrite || action.Kind == ActionAppend {
+			files = append(files, action.Target)
+		}
+	}
+	return files
+}
+
+// SystemdUnits returns the names of the systemd units that would be created
+func (d *DryRunExecutor) SystemdUnits() []string {
+	var units []string
+	for _, file := range d.Files() {
+		if isSystemdUnitPath(file) {
+			units = append(units, filepath.Base(file))
+		}
+	}
+	return units
+}
+
+// Report prints the ordered list of recorded actions followed by a summary
+// of files and systemd units
+func (d *DryRunExecutor) Report(w io.Writer) {
+	fmt.Fprintln(w, "\n📋 Dry run: the following actions would be performed")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	if len(d.Actions) == 0 {
+		fmt.Fprintln(w, "  (nothing)")
+		return
+	}
+
+	for i, action := range d.Actions {
+		switch action.Kind {
+		case ActionCommand:
+			fmt.Fprintf(w, "%3d. run      %s\n", i+1, action.Target)
+		case ActionShell:
+			fmt.Fprintf(w, "%3d. shell    %s\n", i+1, action.Target)
+		case ActionWrite:
+			fmt.Fprintf(w, "%3d. write    %s (%s)\n", i+1, action.Target, action.Detail)
+		case ActionAppend:
+			fmt.Fprintf(w, "%3d. append   %s\n", i+1, action.Target)
+		case ActionDownload:
+			fmt.Fprintf(w, "%3d. download %s -> %s\n", i+1, action.Detail, action.Target)
+		case ActionExtract:
+			fmt.Fprintf(w, "%3d. extract  %s -> %s\n", i+1, action.Detail, action.Target)
+		}
+		if action.Content != "" {
+			for _, line := range strings.Split(strings.TrimRight(action.Content, "\n"), "\n") {
+				fmt.Fprintf(w, "       | %s\n", line)
+			}
+		}
+	}
+
+	if files := d.Files(); len(files) > 0 {
+		fmt.Fprintln(w, "\n📄 Files written:")
+		for _, file := range files {
+			fmt.Fprintf(w, "   - %s\n", file)
+		}
+	}
+
+	if units := d.SystemdUnits(); len(units) > 0 {
+		fmt.Fprintln(w, "\n⚙️  Systemd units created:")
+		for _, unit := range units {
+			fmt.Fprintf(w, "   - %s\n", unit)
+		}
+	}
+}
+
+func isSystemdUnitPath(path string) bool {
+	return strings.HasPrefix(path, "/etc/systemd/system/") && strings.HasSuffix(path, ".service")
+}
+
+// formatCommand renders a command line with shell-style quoting for display
+func formatCommand(command string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, command)
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"$|&;<>(){}*?") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
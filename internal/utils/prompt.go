@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Stdin is the shared reader for interactive prompts. Every prompt must use
+// it so buffered input is not lost between questions.
+var Stdin = bufio.NewReader(os.Stdin)
+
+// assumeYes answers every prompt with its default instead of reading stdin
+var assumeYes bool
+
+// SetAssumeYes makes prompts answer themselves, for unattended installs
+func SetAssumeYes(enabled bool) {
+	assumeYes = enabled
+}
+
+// AssumeYes reports whether prompts are being answered automatically
+func AssumeYes() bool {
+	return assumeYes
+}
+
+// AskYesNo prints a question and reports whether the user answered "y".
+// With --yes the question is answered "y" without waiting for input.
+func AskYesNo(question string) bool {
+	if assumeYes {
+		fmt.Printf("%s (y/n): y\n", question)
+		return true
+	}
+
+	fmt.Printf("%s (y/n): ", question)
+	input, _ := Stdin.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(input)) == "y"
+}
@@ -9,20 +9,35 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 func RunCommand(command string, args ...string) error {
-	cmd := exec.Command(command, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return executor.Run(command, args...)
 }
 
 func RunCommandShell(command string) error {
-	cmd := exec.Command("bash", "-c", command)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return executor.RunShell(command)
+}
+
+// CheckCommand runs a read-only probe directly on the host, even in dry-run
+// mode, and reports whether it exited successfully
+func CheckCommand(command string, args ...string) bool {
+	return exec.Command(command, args...).Run() == nil
+}
+
+// CommandOutput runs a read-only probe directly on the host, even in dry-run
+// mode, and returns its trimmed standard output
+func CommandOutput(command string, args ...string) (string, error) {
+	output, err := exec.Command(command, args...).Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// CommandCombinedOutput is like CommandOutput but also captures standard
+// error, for tools that print their version there
+func CommandCombinedOutput(command string, args ...string) (string, error) {
+	output, err := exec.Command(command, args...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
 }
 
 func PrintInfo(msg string) {
@@ -42,11 +57,26 @@ func PrintWarning(msg string) {
 }
 
 func WriteToFile(filename, content string) error {
-	return os.WriteFile(filename, []byte(content), 0644)
+	return executor.WriteFile(filename, content, 0644)
+}
+
+// WriteFile writes content to a file with the given permissions, using sudo
+// when the destination is not writable by the current user
+func WriteFile(filename, content string, perm os.FileMode) error {
+	return executor.WriteFile(filename, content, perm)
+}
+
+// AppendToFile appends content to an existing file
+func AppendToFile(filename, content string) error {
+	return executor.AppendFile(filename, content)
 }
 
 // DownloadFile downloads a file from a URL to a local path
 func DownloadFile(url, filepath string) error {
+	return executor.DownloadFile(url, filepath)
+}
+
+func downloadFile(url, filepath string) error {
 	resp, err := http.Get(url)
 	if err != nil {
 		return err
@@ -69,6 +99,10 @@ func DownloadFile(url, filepath string) error {
 
 // ExtractTarGz extracts a tar.gz file to a destination directory
 func ExtractTarGz(src, dest string) error {
+	return executor.ExtractTarGz(src, dest)
+}
+
+func extractTarGz(src, dest string) error {
 	file, err := os.Open(src)
 	if err != nil {
 		return err
@@ -130,32 +164,10 @@ func MoveBinaryToSystem(srcPath, binaryName string) error {
 func CreateSystemdService(serviceName, serviceContent string) error {
 	servicePath := fmt.Sprintf("/etc/systemd/system/%s.service", serviceName)
 
-	// Write service content to a temp file first
-	tmpFile, err := os.CreateTemp("", serviceName+"*.service")
-	if err != nil {
-		return err
-	}
-	defer os.Remove(tmpFile.Name())
-
-	if _, err := tmpFile.WriteString(serviceContent); err != nil {
-		tmpFile.Close()
-		return err
-	}
-	tmpFile.Close()
-
-	// Move to systemd directory with sudo
-	if err := RunCommand("sudo", "mv", tmpFile.Name(), servicePath); err != nil {
+	if err := WriteFile(servicePath, serviceContent, 0644); err != nil {
 		return err
 	}
 
 	// Reload systemd daemon
 	return RunCommand("sudo", "systemctl", "daemon-reload")
 }
-
-// EnableAndStartService enables and starts a systemd service
-func EnableAndStartService(serviceName string) error {
-	if err := RunCommand("sudo", "systemctl", "enable", serviceName); err != nil {
-		return err
-	}
-	return RunCommand("sudo", "systemctl", "start", serviceName)
-}